@@ -0,0 +1,381 @@
+package sprintfjs
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hash returns a hash of the structure of the abstract syntax tree.
+// Format strings that differ only in how they are written, e.g. "%' 5.02i" and "%5.2d", hash equal.
+// The hash is stable across runs and platforms.
+func (a AST) Hash() uint64 {
+	h := fnv.New64a()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	for _, n := range a.canonical() {
+		if n.Placeholder == "" {
+			write("text")
+			write(n.Text)
+			continue
+		}
+
+		write("placeholder")
+		write(strconv.Itoa(n.ParamNo))
+		write(strconv.Itoa(len(n.Keys)))
+		for _, key := range n.Keys {
+			write(key)
+		}
+		write(n.Sign)
+		write(n.Alternate)
+		write(n.Grouping)
+		write(n.Case)
+		write(n.Pad)
+		write(n.Align)
+		write(strconv.Itoa(n.Width))
+		write(strconv.FormatBool(n.DynamicWidth))
+		write(n.Precision)
+		write(strconv.FormatBool(n.DynamicPrecision))
+		write(n.Type)
+		write(n.Omit)
+		write(n.Layout)
+		write(strconv.FormatBool(n.HasDefault))
+		write(n.Default)
+	}
+	return h.Sum64()
+}
+
+// Equal reports whether two abstract syntax trees format alike.
+// Placeholders are compared by ParamNo, Keys, Sign, Alternate, Grouping, Case, Pad, Align, Width, DynamicWidth,
+// Precision, DynamicPrecision, Type, Omit, Layout, Default and HasDefault after replacing equivalent notations as `Hash` does,
+// e.g. "%' 5.02i" equals "%5.2d".
+// Placeholder and Offset are ignored, and adjacent text nodes are compared by their concatenated Text.
+// If a.Equal(b) then a.Hash() == b.Hash().
+func (a AST) Equal(b AST) bool {
+	ca, cb := a.canonical(), b.canonical()
+	if len(ca) != len(cb) {
+		return false
+	}
+	for i := range ca {
+		if !ca[i].equal(cb[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonical returns the nodes with adjacent texts merged, e.g. "a", "%", "b" become "a%b",
+// and placeholders normalized.
+// Placeholders keep a non-empty Placeholder to tell them apart from text.
+func (a AST) canonical() []ASTNode {
+	nodes := make([]ASTNode, 0, len(a))
+	text := ""
+	for _, node := range a {
+		if node.Placeholder == "" {
+			text += node.Text
+			continue
+		}
+		if text != "" {
+			nodes = append(nodes, ASTNode{Text: text})
+			text = ""
+		}
+		n := node.normalized()
+		n.Placeholder = "%"
+		nodes = append(nodes, n)
+	}
+	if text != "" {
+		nodes = append(nodes, ASTNode{Text: text})
+	}
+	return nodes
+}
+
+func (n ASTNode) equal(o ASTNode) bool {
+	if len(n.Keys) != len(o.Keys) {
+		return false
+	}
+	for i := range n.Keys {
+		if n.Keys[i] != o.Keys[i] {
+			return false
+		}
+	}
+	return n.Text == o.Text &&
+		n.Placeholder == o.Placeholder &&
+		n.ParamNo == o.ParamNo &&
+		n.Sign == o.Sign &&
+		n.Alternate == o.Alternate &&
+		n.Grouping == o.Grouping &&
+		n.Case == o.Case &&
+		n.Pad == o.Pad &&
+		n.Align == o.Align &&
+		n.Width == o.Width &&
+		n.DynamicWidth == o.DynamicWidth &&
+		n.Precision == o.Precision &&
+		n.DynamicPrecision == o.DynamicPrecision &&
+		n.Type == o.Type &&
+		n.Omit == o.Omit &&
+		n.Layout == o.Layout &&
+		n.Default == o.Default &&
+		n.HasDefault == o.HasDefault
+}
+
+// normalized returns the placeholder with equivalent notations replaced by a canonical one.
+func (n ASTNode) normalized() ASTNode {
+	if n.Pad == "' " {
+		n.Pad = ""
+	}
+	if n.Type == "i" {
+		n.Type = "d"
+	}
+	if n.Precision != "" {
+		if precision, err := strconv.Atoi(n.Precision); err == nil {
+			n.Precision = strconv.Itoa(precision)
+		}
+	}
+	n.Placeholder = ""
+	n.Offset = 0
+	return n
+}
+
+// String returns the canonical format string of the abstract syntax tree, e.g. "%5.2d" for "%' 5.02i".
+// Parsing it yields an abstract syntax tree that is `Equal` to the original one.
+func (a AST) String() string {
+	return a.source(ASTNode.canonicalPlaceholder)
+}
+
+// Format returns the format string of the abstract syntax tree in the notation of its fields, e.g. "%'05.02i",
+// unlike `String`, which replaces equivalent notations. Use it to rewrite format strings, e.g. to rename a key:
+// the placeholders are rebuilt from the fields such as Keys, Sign, Pad, Width, Precision and Type,
+// so changes of these fields are reflected whereas the Placeholder field is ignored. Text is escaped, e.g. "%%".
+func (a AST) Format() string {
+	return a.source(ASTNode.placeholder)
+}
+
+func (a AST) source(placeholder func(ASTNode) string) string {
+	format := strings.Builder{}
+	for _, node := range a {
+		if node.Placeholder == "" {
+			format.WriteString(strings.Replace(node.Text, "%", "%%", -1))
+		} else {
+			format.WriteString(placeholder(node))
+		}
+	}
+	return format.String()
+}
+
+// canonicalPlaceholder returns the placeholder of the node in canonical notation, see `AST.String`.
+func (n ASTNode) canonicalPlaceholder() string {
+	return n.normalized().placeholder()
+}
+
+// placeholder returns the placeholder of the node built from its fields, see `AST.Format`.
+func (n ASTNode) placeholder() string {
+	ph := strings.Builder{}
+	ph.WriteString("%")
+	switch {
+	case n.Keys != nil:
+		ph.WriteString("(" + keyPath(n.Keys))
+		if n.HasDefault {
+			ph.WriteString(":" + n.Default)
+		}
+		if n.Layout != "" {
+			ph.WriteString("|" + n.Layout)
+		}
+		ph.WriteString(")")
+	case n.ParamNo != 0:
+		ph.WriteString(strconv.Itoa(n.ParamNo) + "$")
+	}
+	ph.WriteString(n.Sign + n.Alternate + n.Grouping + n.Case + n.Pad + n.Align)
+	switch {
+	case n.DynamicWidth:
+		ph.WriteString("*")
+	case n.Width > 0:
+		ph.WriteString(strconv.Itoa(n.Width))
+	}
+	switch {
+	case n.DynamicPrecision:
+		ph.WriteString(".*")
+	case n.Precision != "":
+		ph.WriteString("." + n.Precision)
+	}
+	ph.WriteString(n.Type + n.Omit)
+	return ph.String()
+}
+
+// jsonASTNode is the JSON encoding of an `ASTNode`.
+type jsonASTNode struct {
+	Text             string   `json:"text,omitempty"`
+	Placeholder      string   `json:"placeholder,omitempty"`
+	ParamNo          int      `json:"paramNo,omitempty"`
+	Keys             []string `json:"keys,omitempty"`
+	Sign             string   `json:"sign,omitempty"`
+	Alternate        string   `json:"alternate,omitempty"`
+	Grouping         string   `json:"grouping,omitempty"`
+	Case             string   `json:"case,omitempty"`
+	Pad              string   `json:"pad,omitempty"`
+	Align            string   `json:"align,omitempty"`
+	Width            int      `json:"width,omitempty"`
+	DynamicWidth     bool     `json:"dynamicWidth,omitempty"`
+	Precision        string   `json:"precision,omitempty"`
+	DynamicPrecision bool     `json:"dynamicPrecision,omitempty"`
+	Type             string   `json:"type,omitempty"`
+	Omit             string   `json:"omit,omitempty"`
+	Layout           string   `json:"layout,omitempty"`
+	Default          string   `json:"default,omitempty"`
+	HasDefault       bool     `json:"hasDefault,omitempty"`
+	Offset           int      `json:"offset"`
+}
+
+// MarshalJSON encodes the abstract syntax tree as a JSON array of its nodes with camel case keys, e.g.
+// [{"text":"Hello ","offset":0},{"placeholder":"%s","type":"s","offset":6}], to store parsed format strings.
+func (a AST) MarshalJSON() ([]byte, error) {
+	if a == nil {
+		return []byte("null"), nil
+	}
+	nodes := make([]jsonASTNode, len(a))
+	for i, node := range a {
+		nodes[i] = jsonASTNode(node)
+	}
+	return json.Marshal(nodes)
+}
+
+// UnmarshalJSON decodes an abstract syntax tree encoded by `MarshalJSON` without parsing the format string again.
+// Nodes with a type are placeholders; a missing placeholder is set to its canonical notation.
+// Unknown verbs and invalid widths or precisions are an error.
+func (a *AST) UnmarshalJSON(data []byte) error {
+	var nodes []jsonASTNode
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return err
+	}
+	if nodes == nil {
+		*a = nil
+		return nil
+	}
+
+	ast := make(AST, len(nodes))
+	for i, node := range nodes {
+		n := ASTNode(node)
+		if n.Type != "" && n.Placeholder == "" {
+			n.Placeholder = n.canonicalPlaceholder()
+		}
+		if n.Placeholder != "" {
+			if err := n.validate(); err != nil {
+				return err
+			}
+		}
+		ast[i] = n
+	}
+	*a = ast
+	return nil
+}
+
+// validate checks the fields of a placeholder decoded by `UnmarshalJSON`, which cannot be checked while formatting.
+func (n ASTNode) validate() error {
+	if !reVerb.MatchString(n.Type) {
+		return errorf(ErrInvalidPlaceholder, "[sprintf] invalid verb %q of %q", n.Type, n.Placeholder).at(n.Placeholder, 0)
+	}
+	if n.Width < 0 || n.ParamNo < 0 {
+		return errorf(ErrInvalidPlaceholder, "[sprintf] invalid width or positional argument of %q", n.Placeholder).at(n.Placeholder, 0)
+	}
+	if n.Precision != "" {
+		if precision, err := strconv.Atoi(n.Precision); err != nil || precision < 0 {
+			return errorf(ErrInvalidPlaceholder, "[sprintf] invalid precision %q of %q", n.Precision, n.Placeholder).at(n.Placeholder, 0)
+		}
+	}
+	return nil
+}
+
+// Verbs returns the distinct verbs used by the placeholders in ascending order, e.g. "djs" for "%s %d %j %s".
+// Use it to inspect untrusted format strings or restrict them with `Formatter.AllowedVerbs`.
+// Placeholders without a Type, which only constructed abstract syntax trees may have, are skipped.
+func (a AST) Verbs() []byte {
+	verbs := []byte{}
+	for _, node := range a {
+		if node.Placeholder != "" && node.Type != "" && bytes.IndexByte(verbs, node.Type[0]) < 0 {
+			verbs = append(verbs, node.Type[0])
+		}
+	}
+	sort.Slice(verbs, func(i, j int) bool { return verbs[i] < verbs[j] })
+	return verbs
+}
+
+// ArgCount returns the minimum number of arguments formatting the abstract syntax tree requires,
+// i.e. the highest argument index referenced by implicit, explicit positional (%1$s) and dynamic width or precision placeholders,
+// or read by named placeholders as the context argument.
+// It also reports whether named and explicit positional placeholders are used.
+// Use it to detect mismatches like Format("%s %s", "one") before formatting.
+func ArgCount(ast AST) (min int, usesNamed bool, usesPositional bool) {
+	for _, node := range ast {
+		usesNamed = usesNamed || node.Keys != nil
+		usesPositional = usesPositional || node.ParamNo != 0
+	}
+	return ast.numArgs(0), usesNamed, usesPositional
+}
+
+// numArgs returns the number of arguments consumed by formatting the abstract syntax tree.
+func (a AST) numArgs(contextArg int) int {
+	n := 0
+	a.walkArgs(contextArg, func(argNo int) {
+		if argNo > n {
+			n = argNo
+		}
+	})
+	return n
+}
+
+// walkArgs calls `use` with the 1-based number of each argument read by formatting the abstract syntax tree.
+func (a AST) walkArgs(contextArg int, use func(argNo int)) {
+	cursor := 0
+	for _, node := range a {
+		if node.DynamicWidth {
+			cursor++
+			use(cursor)
+		}
+		if node.DynamicPrecision {
+			cursor++
+			use(cursor)
+		}
+		switch {
+		case node.Placeholder == "":
+		case node.Keys != nil:
+			if contextArg > 0 {
+				use(contextArg)
+			} else {
+				use(cursor + 1)
+			}
+		case node.ParamNo != 0:
+			use(node.ParamNo)
+		default:
+			cursor++
+			use(cursor)
+		}
+	}
+}
+
+// SkeletonToken replaces placeholders in `StaticSkeleton`.
+const SkeletonToken = "{}"
+
+// StaticSkeleton returns the static text of the format with each placeholder replaced by `SkeletonToken`,
+// e.g. "user {} did {} things" for "user %s did %d things".
+// This is useful to group log messages by their template.
+func (a AST) StaticSkeleton() string {
+	return a.StaticSkeletonWith(SkeletonToken)
+}
+
+// StaticSkeletonWith is like `StaticSkeleton` but replaces placeholders with `token`.
+func (a AST) StaticSkeletonWith(token string) string {
+	skeleton := strings.Builder{}
+	for _, node := range a {
+		if node.Placeholder == "" {
+			skeleton.WriteString(node.Text)
+		} else {
+			skeleton.WriteString(token)
+		}
+	}
+	return skeleton.String()
+}
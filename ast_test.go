@@ -0,0 +1,316 @@
+package sprintfjs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func mustParse(t *testing.T, format string) sprintfjs.AST {
+	t.Helper()
+	ast, err := sprintfjs.Parse(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ast
+}
+
+func TestASTHash(t *testing.T) {
+	equal := [][2]string{
+		{`%'05.02i`, `%'05.2d`},
+		{`Hello %' 5s!`, `Hello %5s!`},
+		{`100%% %(a.b)s`, `100%% %(a.b)s`},
+	}
+	for _, formats := range equal {
+		if mustParse(t, formats[0]).Hash() != mustParse(t, formats[1]).Hash() {
+			t.Errorf("expected %q and %q to hash equal", formats[0], formats[1])
+		}
+	}
+
+	different := [][2]string{
+		{`%s`, `%v`},
+		{`%5s`, `%-5s`},
+		{`%1$s`, `%2$s`},
+		{`%1$s`, `%s`},
+		{`%(a.b)s`, `%(ab)s`},
+		{`%.2f`, `%.3f`},
+		{`%'x5s`, `%'y5s`},
+		{`%'05s`, `%05s`}, // differ with `Formatter.NoZeroPadStrings`
+		{`a%s`, `%sa`},
+		{`a %s`, `a%s`},
+		{`%s`, `s`},
+		{`%s`, `%s?`},
+	}
+	for _, formats := range different {
+		if mustParse(t, formats[0]).Hash() == mustParse(t, formats[1]).Hash() {
+			t.Errorf("expected %q and %q to hash differently", formats[0], formats[1])
+		}
+	}
+
+	const expected = `%+5.2f %1$s`
+	if mustParse(t, expected).Hash() != mustParse(t, expected).Hash() {
+		t.Errorf("expected hash to be deterministic")
+	}
+}
+
+func TestASTStaticSkeleton(t *testing.T) {
+	testcases := [][2]string{
+		{`user {} did {} things`, `user %s did %d things`},
+		{`{} of {} is 100%`, `%2$s of %1$'_-5.2f is 100%%`},
+		{`Hello {}!`, `Hello %(user.name)s!`},
+		{`no placeholders`, `no placeholders`},
+		{``, ``},
+	}
+	for _, tc := range testcases {
+		if actual := mustParse(t, tc[1]).StaticSkeleton(); tc[0] != actual {
+			t.Errorf("expected %q had %q", tc[0], actual)
+		}
+	}
+
+	if actual := mustParse(t, `%s: %d%%`).StaticSkeletonWith("*"); actual != "*: *%" {
+		t.Errorf("expected %q had %q", "*: *%", actual)
+	}
+}
+
+func TestASTEqual(t *testing.T) {
+	constructed := sprintfjs.AST{
+		{Text: "Hello "},
+		{Text: "dear "},
+		{Placeholder: "%(user.name)s", Keys: []string{"user", "name"}, Width: 5, Pad: "'0", Type: "s"},
+		{Text: "!"},
+	}
+	if !constructed.Equal(mustParse(t, `Hello dear %(user.name)'05s!`)) {
+		t.Errorf("expected constructed AST to equal the parsed one")
+	}
+
+	transformed := mustParse(t, `%5.2f`)
+	transformed[0].Placeholder = "%5.02f"
+	transformed[0].Precision = "02"
+	transformed[0].Offset = 3
+	if !transformed.Equal(mustParse(t, `%5.2f`)) {
+		t.Errorf("expected transformed AST to equal the parsed one")
+	}
+
+	different := [][2]string{
+		{`%s`, `%v`},
+		{`%1$s`, `%2$s`},
+		{`%(a.b)s`, `%(ab)s`},
+		{`%s`, `s`},
+		{`%s %s`, `%s`},
+		{`%s`, `%s?`},
+	}
+	for _, formats := range different {
+		if mustParse(t, formats[0]).Equal(mustParse(t, formats[1])) {
+			t.Errorf("expected %q and %q to differ", formats[0], formats[1])
+		}
+	}
+}
+
+func TestASTVerbs(t *testing.T) {
+	if verbs := string(mustParse(t, `%s scored %d: %j, again %s 100%%`).Verbs()); verbs != "djs" {
+		t.Errorf("expected verbs %q had %q", "djs", verbs)
+	}
+	if verbs := mustParse(t, `no placeholders`).Verbs(); len(verbs) != 0 {
+		t.Errorf("expected no verbs had %q", verbs)
+	}
+	constructed := sprintfjs.AST{{Placeholder: "%s", Type: "s"}, {Placeholder: "%?"}}
+	if verbs := string(constructed.Verbs()); verbs != "s" {
+		t.Errorf("expected verbs %q had %q", "s", verbs)
+	}
+}
+
+func TestArgCount(t *testing.T) {
+	testcases := []struct {
+		format         string
+		min            int
+		usesNamed      bool
+		usesPositional bool
+	}{
+		{`%s %s`, 2, false, false},
+		{`no placeholders`, 0, false, false},
+		{`%2$s %1$s`, 2, false, true},
+		{`%(user.name)s`, 1, true, false},
+		{`%s lives in %(city)s`, 2, true, false},
+		{`%3$s %(city)s`, 3, true, true},
+		{`%*.*f`, 3, false, false},
+	}
+	for _, tc := range testcases {
+		min, usesNamed, usesPositional := sprintfjs.ArgCount(mustParse(t, tc.format))
+		if min != tc.min || usesNamed != tc.usesNamed || usesPositional != tc.usesPositional {
+			t.Errorf("expected %q to report %d, %v, %v had %d, %v, %v",
+				tc.format, tc.min, tc.usesNamed, tc.usesPositional, min, usesNamed, usesPositional)
+		}
+	}
+}
+
+func TestASTString(t *testing.T) {
+	testcases := [][2]string{
+		{`Hello %s!`, `Hello %s!`},
+		{`%'05.2d`, `%'05.02i`},
+		{`%5.2d`, `%' 5.02i`},
+		{`%5s`, `%' 5s`},
+		{`100%% %(a.b)s`, `100%% %(a.b)s`},
+		{`%(items[0].name)s %(items[*])s`, `%(items[0].name)s %(items[*])s`},
+		{`%(date|2006-01-02)s`, `%(date|2006-01-02)s`},
+		{`%2$s %1$'_-5.2f`, `%2$s %1$'_-5.2f`},
+		{`%+#,010.3e`, `%+ #,010.3e`},
+		{`% x`, `% x`},
+		{`%=*.*f`, `%=*.*f`},
+		{`%s?, %d`, `%s?, %d`},
+		{``, ``},
+	}
+	for _, tc := range testcases {
+		ast := mustParse(t, tc[1])
+		if actual := ast.String(); actual != tc[0] {
+			t.Errorf("expected %q had %q", tc[0], actual)
+		}
+		if !mustParse(t, ast.String()).Equal(ast) {
+			t.Errorf("expected %q to parse to an AST equal to the one of %q", ast.String(), tc[1])
+		}
+	}
+
+	formatter := &sprintfjs.Formatter{NoZeroPadStrings: true}
+	for _, format := range []string{`%'05s|%05s`, `%'05q|%05q`, `%' 5s|%'x5s`} {
+		expected, err := formatter.Format(format, "<", "<")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, _ := formatter.FormatAST(mustParse(t, mustParse(t, format).String()), "<", "<"); actual != expected {
+			t.Errorf("expected the canonical format string of %q to yield %q, had %q", format, expected, actual)
+		}
+	}
+
+	constructed := sprintfjs.AST{
+		{Text: "50% of "},
+		{Placeholder: "%(user.name)s", Keys: []string{"user", "name"}, Width: 5, Type: "s"},
+	}
+	if actual := constructed.String(); actual != `50%% of %(user.name)5s` {
+		t.Errorf("expected the text of constructed ASTs to be escaped, had %q", actual)
+	}
+}
+
+func TestASTJSON(t *testing.T) {
+	for _, format := range []string{
+		`Hello %(user.name)s, you are %+5.1f%% done`,
+		`%2$s %1$'_-5.2f %*.*d %(items[*].id)j?`,
+		`%(date|2006-01-02)s`,
+		``,
+	} {
+		ast := mustParse(t, format)
+		data, err := json.Marshal(ast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded sprintfjs.AST
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("failed to decode %s: %v", data, err)
+		}
+		if !reflect.DeepEqual(decoded, ast) {
+			t.Errorf("expected %s to decode to the AST of %q, had %#v", data, format, decoded)
+		}
+	}
+
+	data, _ := json.Marshal(mustParse(t, `Hi %5s`))
+	if expected := `[{"text":"Hi ","offset":0},{"placeholder":"%5s","width":5,"type":"s","offset":3}]`; string(data) != expected {
+		t.Errorf("expected %s had %s", expected, data)
+	}
+
+	var ast sprintfjs.AST
+	if err := json.Unmarshal([]byte(`[{"text":"Hi "},{"keys":["name"],"type":"s"}]`), &ast); err != nil {
+		t.Fatal(err)
+	}
+	if ast[1].Placeholder != "%(name)s" {
+		t.Errorf("expected a missing placeholder to be reconstructed, had %q", ast[1].Placeholder)
+	}
+	if s, err := sprintfjs.FormatAST(ast, map[string]string{"name": "Bob"}); err != nil || s != "Hi Bob" {
+		t.Errorf("expected the decoded AST to format, had %q, %v", s, err)
+	}
+
+	for _, data := range []string{
+		`[{"placeholder":"%y","type":"y"}]`,
+		`[{"placeholder":"%s"}]`,
+		`[{"type":"f","precision":"x"}]`,
+		`[{"type":"s","width":-1}]`,
+	} {
+		if err := json.Unmarshal([]byte(data), &ast); !errors.Is(err, sprintfjs.ErrInvalidPlaceholder) {
+			t.Errorf("expected %s to fail, had %v", data, err)
+		}
+	}
+}
+
+func TestASTFormat(t *testing.T) {
+	for _, format := range []string{
+		`Hello %s!`,
+		`%'05.02i`,
+		`%' 5s`,
+		`100%% %(a.b)s`,
+		`%(items[0].name)s %(items[*])s`,
+		`%(date|2006-01-02)s`,
+		`%2$s %1$'_-5.2f`,
+		`%+#,010.3e`,
+		`%=*.*f`,
+		`%s?, %d`,
+	} {
+		if actual := mustParse(t, format).Format(); actual != format {
+			t.Errorf("expected %q had %q", format, actual)
+		}
+	}
+
+	renamed := mustParse(t, `Hello %(user.name)-10s, 100%% of %(user.name)s`)
+	for i := range renamed {
+		if renamed[i].Keys != nil {
+			renamed[i].Keys = []string{"account", "displayName"}
+		}
+	}
+	if expected, actual := `Hello %(account.displayName)-10s, 100%% of %(account.displayName)s`, renamed.Format(); actual != expected {
+		t.Errorf("expected %q had %q", expected, actual)
+	}
+}
+
+func TestASTFormatRandom(t *testing.T) {
+	const alphabet = "%%%%%$()+ #,0'-=*.1290abdfijsvxC?|[]_."
+	rnd := rand.New(rand.NewSource(1))
+	formatters := []*sprintfjs.Formatter{{}, {NoZeroPadStrings: true}}
+	args := []interface{}{"<", 1.5, 42, map[string]interface{}{"a": "b"}}
+
+	parsed := 0
+	for i := 0; i < 20000; i++ {
+		b := make([]byte, 1+rnd.Intn(12))
+		for j := range b {
+			b[j] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		ast, err := sprintfjs.Parse(string(b))
+		if err != nil {
+			continue
+		}
+		parsed++
+
+		for _, reconstruct := range []func(sprintfjs.AST) string{sprintfjs.AST.Format, sprintfjs.AST.String} {
+			format := reconstruct(ast)
+			reparsed, err := sprintfjs.Parse(format)
+			if err != nil {
+				t.Fatalf("failed to parse %q reconstructed from %q: %v", format, b, err)
+			}
+			if !reparsed.Equal(ast) {
+				t.Fatalf("expected %q reconstructed from %q to parse alike", format, b)
+			}
+			if again := reconstruct(reparsed); again != format {
+				t.Fatalf("expected %q reconstructed from %q to be stable, had %q", format, b, again)
+			}
+			for _, formatter := range formatters {
+				expected, experr := formatter.FormatAST(ast, args...)
+				actual, err := formatter.FormatAST(reparsed, args...)
+				if actual != expected || (err == nil) != (experr == nil) {
+					t.Fatalf("expected %q reconstructed from %q to yield %q, had %q", format, b, expected, actual)
+				}
+			}
+		}
+	}
+	if parsed < 1000 {
+		t.Errorf("expected more random format strings to parse, had %d", parsed)
+	}
+}
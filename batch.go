@@ -0,0 +1,25 @@
+package sprintfjs
+
+// Entry is a format string along with its arguments, see `FormatAll`.
+type Entry struct {
+	Format string
+	Args   []interface{}
+}
+
+// FormatAll formats each entry independently, e.g. the sections of a report.
+// Unlike formatting the entries one by one and stopping at the first error, it reports all broken entries at once.
+// The results and errors are parallel to the entries: a failed entry has an empty result and an error,
+// a formatted entry a nil error.
+func FormatAll(entries []Entry) ([]string, []error) {
+	return defaultFormatter.FormatAll(entries)
+}
+
+// FormatAll formats each entry independently. See `FormatAll`.
+func (f *Formatter) FormatAll(entries []Entry) ([]string, []error) {
+	results := make([]string, len(entries))
+	errs := make([]error, len(entries))
+	for i, entry := range entries {
+		results[i], errs[i] = f.Format(entry.Format, entry.Args...)
+	}
+	return results, errs
+}
@@ -0,0 +1,42 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatAll(t *testing.T) {
+	entries := []sprintfjs.Entry{
+		{Format: `Hello %s!`, Args: []interface{}{"world"}},
+		{Format: `%d items`, Args: []interface{}{"many"}},
+		{Format: `no placeholders`},
+		{Format: `%y`},
+		{Format: `%s and %s`, Args: []interface{}{"a"}},
+		{Format: `%.2f`, Args: []interface{}{3.14159}},
+	}
+
+	results, errs := sprintfjs.FormatAll(entries)
+	if len(results) != len(entries) || len(errs) != len(entries) {
+		t.Fatalf("expected %d results and errors, had %d and %d", len(entries), len(results), len(errs))
+	}
+
+	expected := []string{`Hello world!`, ``, `no placeholders`, ``, ``, `3.14`}
+	for i := range entries {
+		if results[i] != expected[i] {
+			t.Errorf("expected entry %d to be %q, had %q", i, expected[i], results[i])
+		}
+		if failed := expected[i] == ""; failed != (errs[i] != nil) {
+			t.Errorf("unexpected error of entry %d: %v", i, errs[i])
+		}
+	}
+
+	results, errs = (&sprintfjs.Formatter{Locale: "de"}).FormatAll([]sprintfjs.Entry{{Format: `%.1f`, Args: []interface{}{1.5}}})
+	if results[0] != "1,5" || errs[0] != nil {
+		t.Errorf("expected the formatter options to apply, had %q, %v", results[0], errs[0])
+	}
+
+	if results, errs := sprintfjs.FormatAll(nil); len(results) != 0 || len(errs) != 0 {
+		t.Errorf("expected no results for no entries")
+	}
+}
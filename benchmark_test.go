@@ -0,0 +1,38 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func BenchmarkFormatAST(b *testing.B) {
+	ast := sprintfjs.MustParse(`%s scored %d points (%.1f%%), %+5d, %x %v %t`)
+	args := []interface{}{"alice", 42, 87.5, -3, 255, "v", true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := sprintfjs.FormatAST(ast, args...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatConstant(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := sprintfjs.Format("connection to the database established"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatTemplate(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := sprintfjs.Format("user %s logged in from %s after %d attempts", "alice", "10.0.0.1", 3); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
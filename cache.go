@@ -0,0 +1,38 @@
+package sprintfjs
+
+import "sync"
+
+// cache holds the abstract syntax trees of `FormatCached` by format string.
+var cache sync.Map
+
+// FormatCached is like `Format` but parses each distinct format string only once.
+// The parsed format strings are kept until `ClearCache` is called, so use it for a bounded set of
+// format strings, e.g. constants, and `Format` for format strings that vary, e.g. user input.
+// It is safe for concurrent use.
+func FormatCached(format string, args ...interface{}) (string, error) {
+	ast, err := parseCached(format)
+	if err != nil {
+		return "", err
+	}
+	return FormatAST(ast, args...)
+}
+
+// ClearCache drops the format strings parsed by `FormatCached`.
+func ClearCache() {
+	cache.Range(func(key, _ interface{}) bool {
+		cache.Delete(key)
+		return true
+	})
+}
+
+func parseCached(format string) (AST, error) {
+	if ast, ok := cache.Load(format); ok {
+		return ast.(AST), nil
+	}
+	ast, err := Parse(format)
+	if err != nil {
+		return nil, err // not cached, failures are expected to be rare
+	}
+	cache.Store(format, ast)
+	return ast, nil
+}
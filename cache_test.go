@@ -0,0 +1,44 @@
+package sprintfjs_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatCached(t *testing.T) {
+	defer sprintfjs.ClearCache()
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			format := fmt.Sprintf("%%s #%d: %%05.1f", i%5)
+			actual, err := sprintfjs.FormatCached(format, "item", float64(i))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if expected := fmt.Sprintf("item #%d: %05.1f", i%5, float64(i)); expected != actual {
+				errs <- fmt.Errorf("expected %q had %q", expected, actual)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	sprintfjs.ClearCache()
+	if actual, err := sprintfjs.FormatCached(`%s #0: %05.1f`, "item", 1.0); err != nil || actual != "item #0: 001.0" {
+		t.Fatalf("expected formatting after ClearCache had %q, %v", actual, err)
+	}
+	if _, err := sprintfjs.FormatCached(`%y`); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -0,0 +1,68 @@
+package sprintfjs
+
+import "strings"
+
+// CellOverflow is the policy of the `%C` verb for values wider than the cell.
+type CellOverflow int
+
+const (
+	// CellEllipsis truncates values and marks the truncation by an ellipsis, e.g. "Hell…".
+	CellEllipsis CellOverflow = iota
+	// CellClip truncates values, e.g. "Hello".
+	CellClip
+	// CellWrap breaks values into lines of the cell width separated by "\n".
+	// The result spans multiple lines, so it only suits cells that are the last column of a row.
+	CellWrap
+)
+
+// cellEllipsis marks truncated values of `CellEllipsis`.
+const cellEllipsis = "…"
+
+// formatCell fits a value into exactly `width` characters, or terminal columns if `EastAsianWidth` is set.
+// A negative width left-aligns the cell. A cell without width is the value as is.
+func (f *Formatter) formatCell(value string, width int, padChar string, align string) string {
+	if width < 0 {
+		width, align = -width, AlignLeft
+	}
+	if width == 0 {
+		return value
+	}
+	if f.stringWidth(value) <= width {
+		return f.alignedPad(value, width, padChar, align, "", "")
+	}
+
+	runes := []rune(value)
+	switch f.CellOverflow {
+	case CellClip:
+		head, _ := f.cutCell(runes, width)
+		return f.alignedPad(string(head), width, padChar, align, "", "")
+	case CellWrap:
+		lines := []string{}
+		for f.stringWidth(string(runes)) > width {
+			var head []rune
+			if head, runes = f.cutCell(runes, width); len(head) == 0 {
+				head, runes = runes[:1], runes[1:] // a wide character in a cell of one column
+			}
+			lines = append(lines, f.alignedPad(string(head), width, padChar, align, "", ""))
+		}
+		if len(runes) > 0 {
+			lines = append(lines, f.alignedPad(string(runes), width, padChar, align, "", ""))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		head, _ := f.cutCell(runes, width-f.stringWidth(cellEllipsis))
+		return f.alignedPad(string(head)+cellEllipsis, width, padChar, align, "", "")
+	}
+}
+
+// cutCell splits runes into the longest head that fits into `width` and the rest.
+// The head may be narrower than `width` if the next character is wide, so that padding fills the cell.
+func (f *Formatter) cutCell(runes []rune, width int) (head []rune, rest []rune) {
+	used := 0
+	for i, r := range runes {
+		if used += f.stringWidth(string(r)); used > width {
+			return runes[:i], runes[i:]
+		}
+	}
+	return runes, nil
+}
@@ -0,0 +1,56 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatCell(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`   Hello`, `%8C`, "Hello"),
+		ftc(`Hello   `, `%-8C`, "Hello"),
+		ftc(` Hello  `, `%=8C`, "Hello"),
+		ftc(`__été___`, `%'_=8C`, "été"),
+		ftc(`Grüße, …`, `%8C`, "Grüße, Welt"),
+		ftc(`    1234`, `%8C`, 1234),
+		ftc(`Hel     `, `%-8.3C`, "Hello"),
+		ftc(`Hello`, `%C`, "Hello"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{CellOverflow: sprintfjs.CellClip}, []formatterTestcase{
+		ftc(`Grüße, W`, `%8C`, "Grüße, Welt"),
+		ftc(`日本語`, `%=3C`, "日本語です"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{CellOverflow: sprintfjs.CellWrap}, []formatterTestcase{
+		ftc("Grüß\ne, W\nelt ", `%-4C`, "Grüße, Welt"),
+		ftc("abcd", `%4C`, "abcd"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{EastAsianWidth: true}, []formatterTestcase{
+		ftc(` 日…`, `%4C`, "日本語です"),
+		ftc(`日本語`, `%6C`, "日本語"),
+		ftc(`日本 `, `%-5C`, "日本"),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{EastAsianWidth: true, CellOverflow: sprintfjs.CellClip}, []formatterTestcase{
+		ftc(`日 `, `%-3C`, "日本語です"),
+		ftc(`日本`, `%4C`, "日本語です"),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{EastAsianWidth: true, CellOverflow: sprintfjs.CellWrap}, []formatterTestcase{
+		ftc("日本\n語で\nす  ", `%-4C`, "日本語です"),
+		ftc("日\n本", `%1C`, "日本"),
+	})
+}
+
+func TestFormatCenter(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`  ab   `, `%=7s`, "ab"),
+		ftc(`  -3   `, `%=7d`, -3),
+		ftc(`toolong`, `%=3s`, "toolong"),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{DefaultAlign: sprintfjs.AlignCenter}, []formatterTestcase{
+		ftc(` ab `, `%4s`, "ab"),
+		ftc(`ab  `, `%-4s`, "ab"),
+	})
+}
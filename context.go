@@ -0,0 +1,45 @@
+package sprintfjs
+
+import (
+	"context"
+	"io"
+)
+
+// FormatContext formats like `Format` but stops once `ctx` is done and returns `ctx.Err()`, e.g. when the request of
+// a server handler is cancelled while formatting huge values.
+// The context is checked before each placeholder and, while reading an io.Reader for %s, before each read.
+// Formatting a single value is not interrupted otherwise, e.g. encoding a value for %j runs to completion.
+func FormatContext(ctx context.Context, format string, args ...interface{}) (string, error) {
+	return defaultFormatter.FormatContext(ctx, format, args...)
+}
+
+// FormatContext formats like `Format` but stops once `ctx` is done. See `FormatContext`.
+func (f *Formatter) FormatContext(ctx context.Context, format string, args ...interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	cf := *f
+	cf.ctx = ctx
+	return cf.Format(format, args...)
+}
+
+// canceled returns the error of the context of `FormatContext` once it is done.
+func (f *Formatter) canceled() error {
+	if f.ctx == nil {
+		return nil
+	}
+	return f.ctx.Err()
+}
+
+// contextReader fails reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,67 @@
+package sprintfjs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+// cancelingStringer cancels a context when it is formatted.
+type cancelingStringer struct {
+	cancel context.CancelFunc
+}
+
+func (s cancelingStringer) String() string {
+	s.cancel()
+	return "canceled"
+}
+
+// endlessReader cancels a context on its first read and never ends.
+type endlessReader struct {
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.reads++
+	r.cancel()
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestFormatContext(t *testing.T) {
+	s, err := sprintfjs.FormatContext(context.Background(), `Hello %s, you are %d`, "alice", 42)
+	if err != nil || s != `Hello alice, you are 42` {
+		t.Errorf("expected formatting to succeed, had %q, %v", s, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s, err := sprintfjs.FormatContext(ctx, `%s`, "a"); err != context.Canceled || s != "" {
+		t.Errorf("expected a cancelled context to fail, had %q, %v", s, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	if _, err := sprintfjs.FormatContext(ctx, `%s %s`, cancelingStringer{cancel}, "b"); err != context.Canceled {
+		t.Errorf("expected formatting to stop before the next placeholder, had %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	r := &endlessReader{cancel: cancel}
+	if _, err := sprintfjs.FormatContext(ctx, `%s`, r); err != context.Canceled || r.reads != 1 {
+		t.Errorf("expected reading to stop after %d reads, had %v", r.reads, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	formatter := &sprintfjs.Formatter{Locale: "de"}
+	if _, err := formatter.FormatContext(ctx, `%.1f`, 1.5); err != context.DeadlineExceeded {
+		t.Errorf("expected an expired deadline to fail, had %v", err)
+	}
+	if s, _ := formatter.FormatContext(context.Background(), `%.1f`, 1.5); s != "1,5" {
+		t.Errorf("expected the formatter options to apply, had %q", s)
+	}
+}
@@ -0,0 +1,135 @@
+package sprintfjs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of errors returned by parsing and formatting. Test for them with `errors.Is`, e.g.
+// errors.Is(err, sprintfjs.ErrTooFewArgs).
+var (
+	// ErrInvalidPlaceholder is the kind of format strings that cannot be parsed, e.g. "%y" or a width that is too large.
+	ErrInvalidPlaceholder = errors.New("invalid placeholder")
+	// ErrTooFewArgs is the kind of placeholders referring to a missing argument, e.g. Format("%s %s", "a").
+	ErrTooFewArgs = errors.New("too few arguments")
+	// ErrTypeMismatch is the kind of values that cannot be formatted as their placeholder requires,
+	// e.g. Format("%d", "abc"), including values failing to produce their contents, e.g. a failing io.Reader.
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrInvalidKey is the kind of named placeholders whose keys cannot be looked up, e.g. an index out of range.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrUnusedArg is the kind of arguments that are never used, see `Formatter.Strict`.
+	ErrUnusedArg = errors.New("unused argument")
+	// ErrVerbNotAllowed is the kind of verbs rejected by `Formatter.AllowedVerbs`.
+	ErrVerbNotAllowed = errors.New("verb not allowed")
+	// ErrOutputLimit is the kind of output exceeding `Formatter.MaxOutputBytes`.
+	ErrOutputLimit = errors.New("output limit exceeded")
+)
+
+// Error is an error of parsing or formatting. Use `errors.As` to read the placeholder and the argument that caused it.
+// Errors of the `Formatter` options, e.g. an unknown locale, are not of this type.
+type Error struct {
+	Kind        error  // one of the Err kinds, e.g. `ErrTooFewArgs`
+	Placeholder string // the placeholder, e.g. "%(name)s", if the error is caused by one
+	Arg         int    // the 1-based index of the argument, if the error is caused by one
+	Err         error  // the underlying error, if any
+	msg         string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether `target` is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is the error of a format string that cannot be parsed or fails `Validate`.
+// Its offset and text tell where the problem is, e.g. to underline it in an editor.
+type ParseError struct {
+	Format string // the format string
+	Offset int    // byte offset of the invalid placeholder in Format
+	Text   string // the invalid placeholder, e.g. "%y", or the beginning of it if it cannot be told where it ends
+	Err    *Error // the error of the placeholder
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %q", e.Err.Error(), e.Offset, e.Text)
+}
+
+// Unwrap returns the error of the placeholder, so that errors.Is(err, ErrInvalidPlaceholder) holds.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// errorf returns an error of the kind `kind` with a formatted message.
+func errorf(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// wrapf is like `errorf` with an underlying error.
+func wrapf(kind error, err error, format string, args ...interface{}) *Error {
+	e := errorf(kind, format, args...)
+	e.Err = err
+	return e
+}
+
+// at sets the placeholder and the argument of the error unless known already.
+func (e *Error) at(placeholder string, arg int) *Error {
+	if e.Placeholder == "" {
+		e.Placeholder = placeholder
+	}
+	if e.Arg == 0 {
+		e.Arg = arg
+	}
+	return e
+}
+
+// errorAt sets the placeholder and the argument of an `*Error`. Other errors are returned as they are.
+func errorAt(err error, placeholder string, arg int) error {
+	var e *Error
+	if errors.As(err, &e) {
+		e.at(placeholder, arg)
+	}
+	return err
+}
+
+// kindOf returns the kind of an `*Error`, or `fallback` for other errors.
+func kindOf(err error, fallback error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return fallback
+}
+
+// errorChain joins the messages of an error and the errors it wraps.
+// The message of a wrapped error is removed from the end of the message of the error wrapping it,
+// e.g. "read config: open file: not found" becomes ["read config", "open file", "not found"].
+func (f *Formatter) errorChain(err error) string {
+	separator := f.ErrorChainSeparator
+	if separator == "" {
+		separator = ": "
+	}
+
+	messages := []string{}
+	for err != nil {
+		message := err.Error()
+		next := errors.Unwrap(err)
+		if next != nil {
+			message = strings.TrimSuffix(message, next.Error())
+			message = strings.TrimRight(strings.TrimSuffix(message, ": "), " ")
+		}
+		if message != "" {
+			messages = append(messages, message)
+		}
+		err = next
+	}
+	return strings.Join(messages, separator)
+}
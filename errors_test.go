@@ -0,0 +1,140 @@
+package sprintfjs_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatErrorChain(t *testing.T) {
+	root := errors.New("not found")
+	wrapped := fmt.Errorf("read config: %w", fmt.Errorf("open file: %w", root))
+
+	runFormatterTests(t, &sprintfjs.Formatter{ErrorChain: true}, []formatterTestcase{
+		ftc(`read config: open file: not found`, `%v`, wrapped),
+		ftc(`not found`, `%v`, root),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ErrorChain: true, ErrorChainSeparator: " <- "}, []formatterTestcase{
+		ftc(`read config <- open file <- not found`, `%v`, wrapped),
+		ftc(`not found`, `%v`, root),
+		ftc(`read config <- open`, `%.19v`, wrapped),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ErrorChainSeparator: " <- "}, []formatterTestcase{
+		ftc(`read config: open file: not found`, `%v`, wrapped),
+	})
+}
+
+func TestErrorKinds(t *testing.T) {
+	type testcase struct {
+		Kind        error
+		Placeholder string
+		Arg         int
+		Format      string
+		Args        []interface{}
+	}
+	testcases := []testcase{
+		{sprintfjs.ErrInvalidPlaceholder, `%y`, 0, `%y`, nil},
+		{sprintfjs.ErrInvalidPlaceholder, `%(a..b)s`, 0, `%(a..b)s`, nil},
+		{sprintfjs.ErrTooFewArgs, `%s`, 2, `%s %s`, []interface{}{"a"}},
+		{sprintfjs.ErrTooFewArgs, `%3$s`, 3, `%3$s`, []interface{}{"a"}},
+		{sprintfjs.ErrTooFewArgs, `%*d`, 1, `%*d`, nil},
+		{sprintfjs.ErrTypeMismatch, `%d`, 2, `%s %d`, []interface{}{"a", "b"}},
+		{sprintfjs.ErrTypeMismatch, `%2$c`, 2, `%1$s %2$c`, []interface{}{"a", []int{1}}},
+		{sprintfjs.ErrTypeMismatch, `%*d`, 1, `%*d`, []interface{}{"wide", 1}},
+		{sprintfjs.ErrTypeMismatch, `%j`, 1, `%j`, []interface{}{make(chan int)}},
+		{sprintfjs.ErrInvalidKey, `%(a.b)s`, 1, `%(a.b)s`, []interface{}{map[string]interface{}{"a": nil}}},
+		{sprintfjs.ErrInvalidKey, `%(list[2])s`, 1, `%(list[2])s`, []interface{}{map[string]interface{}{"list": []int{1}}}},
+	}
+
+	for _, tc := range testcases {
+		_, err := sprintfjs.Format(tc.Format, tc.Args...)
+		if !errors.Is(err, tc.Kind) {
+			t.Errorf("expected %q to fail with %v, had %v", tc.Format, tc.Kind, err)
+			continue
+		}
+		var e *sprintfjs.Error
+		if !errors.As(err, &e) {
+			t.Errorf("expected %q to fail with a *sprintfjs.Error, had %T", tc.Format, err)
+			continue
+		}
+		if e.Placeholder != tc.Placeholder || e.Arg != tc.Arg {
+			t.Errorf("expected %q to fail at %q, argument %d, had %q, argument %d", tc.Format, tc.Placeholder, tc.Arg, e.Placeholder, e.Arg)
+		}
+	}
+
+	if _, err := (&sprintfjs.Formatter{Strict: true}).Format(`%s`, "a", "b"); !errors.Is(err, sprintfjs.ErrUnusedArg) {
+		t.Errorf("expected an unused argument error, had %v", err)
+	} else if e := err.(*sprintfjs.Error); e.Arg != 2 {
+		t.Errorf("expected the unused argument 2, had %d", e.Arg)
+	}
+	if _, err := (&sprintfjs.Formatter{AllowedVerbs: "s"}).Format(`%d`, 1); !errors.Is(err, sprintfjs.ErrVerbNotAllowed) {
+		t.Errorf("expected a verb error, had %v", err)
+	}
+	if _, err := (&sprintfjs.Formatter{MaxOutputBytes: 2}).Format(`%s`, "abc"); !errors.Is(err, sprintfjs.ErrOutputLimit) {
+		t.Errorf("expected an output limit error, had %v", err)
+	}
+
+	cause := errors.New("cannot marshal")
+	_, err := sprintfjs.Format(`%s`, textMarshaler{err: cause})
+	if !errors.Is(err, sprintfjs.ErrTypeMismatch) || !errors.Is(err, cause) {
+		t.Errorf("expected a type mismatch wrapping the cause, had %v", err)
+	}
+	if errors.Is(err, sprintfjs.ErrTooFewArgs) {
+		t.Errorf("expected a type mismatch only, had %v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	type testcase struct {
+		Format string
+		Offset int
+		Text   string
+	}
+	testcases := []testcase{
+		{`%y`, 0, `%y`},
+		{`Hello %s, you are %y years old`, 18, `%y`},
+		{`100%`, 3, `%`},
+		{`%s %5.q`, 3, `%5.q`},
+		{`日本 %(a..b)s`, 7, `%(a..b)s`},
+		{`%s %(name|)s`, 3, `%(name|)s`},
+		{`%% %99999999d`, 3, `%99999999d`},
+		{`%s %1234567890123456789`, 3, `%123456789012345`},
+		{`%é years`, 0, `%é`},
+		{`%s %'€€€€€€€`, 3, `%'€€€€`},
+	}
+
+	for _, tc := range testcases {
+		_, err := sprintfjs.Parse(tc.Format)
+		var parseErr *sprintfjs.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("expected %q to fail with a *sprintfjs.ParseError, had %v", tc.Format, err)
+			continue
+		}
+		if parseErr.Format != tc.Format || parseErr.Offset != tc.Offset || parseErr.Text != tc.Text {
+			t.Errorf("expected %q to fail at %d with %q, had %d with %q", tc.Format, tc.Offset, tc.Text, parseErr.Offset, parseErr.Text)
+		}
+		if !errors.Is(err, sprintfjs.ErrInvalidPlaceholder) {
+			t.Errorf("expected %q to fail with an invalid placeholder, had %v", tc.Format, err)
+		}
+		if !utf8.ValidString(parseErr.Text) {
+			t.Errorf("expected the text %q of %q to be valid UTF-8", parseErr.Text, tc.Format)
+		}
+		if tc.Format[parseErr.Offset:parseErr.Offset+len(parseErr.Text)] != parseErr.Text {
+			t.Errorf("expected the text %q at offset %d of %q", parseErr.Text, parseErr.Offset, tc.Format)
+		}
+	}
+
+	_, err := sprintfjs.Format(`total: %y`, 1)
+	if expected := `[sprintf] unexpected placeholder at offset 7: "%y"`; err == nil || err.Error() != expected {
+		t.Errorf("expected %q, had %v", expected, err)
+	}
+	_, err = (&sprintfjs.Formatter{AllowedVerbs: "s"}).Parse(`%s %d`)
+	if !errors.Is(err, sprintfjs.ErrVerbNotAllowed) {
+		t.Errorf("expected a verb error, had %v", err)
+	}
+}
@@ -0,0 +1,785 @@
+package sprintfjs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter formats values according to its options.
+// The zero value formats just like the package level functions, which use a shared zero Formatter.
+// Subsystems needing other options, e.g. a locale or output limits, configure a Formatter of their own
+// instead of changing global state. A Formatter is safe for concurrent use as long as its options are not modified.
+type Formatter struct {
+	// DereferencePointers makes all verbs format the value a pointer points to instead of the pointer itself.
+	// Pointers implementing `fmt.Stringer` are not dereferenced.
+	DereferencePointers bool
+
+	// NilText is rendered by all verbs except %j for null values: untyped nil, nil pointers and `driver.Valuer`s
+	// without a value, e.g. an invalid `sql.NullString`. %j always renders null values as null.
+	// If empty, null values are formatted like any other value,
+	// except that formatting a nil pointer is an error when `DereferencePointers` is set.
+	NilText string
+
+	// NullAsZero makes numeric verbs format null values as 0, see `NilText`.
+	NullAsZero bool
+
+	// MaxOutputBytes limits the size of the formatted output in bytes. Zero means no limit.
+	// Exceeding the limit is an error unless `OutputLimitMarker` is set.
+	// Padding and the JSON of %j are written only as far as they fit.
+	MaxOutputBytes int
+
+	// OutputLimitMarker is appended to output that was truncated at `MaxOutputBytes`.
+	// If empty, exceeding `MaxOutputBytes` is an error.
+	OutputLimitMarker string
+
+	// MaxWidth is the largest width or precision accepted. Zero means `DefaultMaxWidth`.
+	MaxWidth int
+
+	// Now returns the reference time for relative times (`%R`). Defaults to `time.Now`.
+	Now func() time.Time
+
+	// EnumLabels maps values of integer types to labels rendered by `%s`, keyed by the Go type.
+	// Values without a label are rendered as plain numbers.
+	EnumLabels map[reflect.Type]map[int64]string
+
+	// OctalPrefix0o makes the alternate form of octal numbers (`%#o`) use the prefix "0o" instead of "0".
+	OctalPrefix0o bool
+
+	// HashAlgorithm is the algorithm used by `%H`. Defaults to `HashFNV`.
+	HashAlgorithm HashAlgorithm
+
+	// UpperCaseUUIDs makes `%U` use upper-case hexadecimal digits.
+	UpperCaseUUIDs bool
+
+	// BoolAsNumber renders booleans as 1 and 0, except for `%t`, `%T` and `%j`.
+	// Numeric verbs, e.g. `%d`, always render booleans as 1 and 0 like JavaScript.
+	BoolAsNumber bool
+
+	// ListSeparator joins the values collected by a [*] wildcard and elements formatted by `ElementFormat`.
+	// Defaults to ", ".
+	ListSeparator string
+
+	// Locale selects the decimal and grouping separators of numbers, e.g. "en", "de" or "de-CH".
+	// Defaults to "en". Unknown locales are an error.
+	Locale string
+
+	// DecimalSeparator overrides the decimal separator of the locale.
+	DecimalSeparator string
+
+	// GroupSeparator overrides the grouping separator (`,` flag) of the locale.
+	GroupSeparator string
+
+	// GroupSize overrides the number of digits per group of the locale.
+	GroupSize int
+
+	// MaxPrecision is the largest precision of floating point numbers. Zero means `DefaultMaxPrecision`.
+	// Larger precisions are an error unless `ClampPrecision` is set.
+	MaxPrecision int
+
+	// ClampPrecision silently reduces floating point precisions larger than `MaxPrecision`.
+	ClampPrecision bool
+
+	// ErrorChain makes `%v` of an error render the messages of all wrapped errors joined by `ErrorChainSeparator`.
+	ErrorChain bool
+
+	// ErrorChainSeparator joins the messages of wrapped errors. Defaults to ": ".
+	ErrorChainSeparator string
+
+	// NoZeroPadStrings makes the 0 flag pad %s and %q with spaces like C's printf, e.g. %05s of "<" yields "    <".
+	// By default strings are padded with zeros like by sprintf.js, e.g. 0000<. Explicit padding with '0 still uses zeros.
+	NoZeroPadStrings bool
+
+	// DefaultAlign is the alignment of placeholders without the - or = flag, `AlignRight`, `AlignLeft` or `AlignCenter`.
+	DefaultAlign string
+
+	// CollapseWhitespace makes `%s` replace runs of whitespace in strings by a single space and trim both ends.
+	CollapseWhitespace bool
+
+	// EngineeringNotation makes `%e` use exponents that are multiples of three, e.g. 12.3e+3 instead of 1.23e+4.
+	EngineeringNotation bool
+
+	// NoNegativeZero makes %e, %f and %g, as well as %E and %G, render negative numbers that round to zero
+	// and negative zero without a minus sign, e.g. "%.1f" of -0.01 yields 0.0 instead of -0.0.
+	NoNegativeZero bool
+
+	// Rounding is how %e, %f and %g, as well as %E and %G, round numbers to their precision, see `RoundingMode`.
+	// It does not apply to %e with `EngineeringNotation`.
+	Rounding RoundingMode
+
+	// Exponent is the number of exponent digits of %e, %E, %g and %G, see `ExponentStyle`.
+	Exponent ExponentStyle
+
+	// ContextArg is the 1-based index of the argument named placeholders read their keys from.
+	// Zero means the argument at the implicit cursor. Named placeholders never advance the cursor,
+	// so unless implicit placeholders advanced it before, this is the first argument.
+	ContextArg int
+
+	// ElementFormat is a format string applied to each element of slices and arrays by `%v`, e.g. "%.2f".
+	// The formatted elements are joined by `ListSeparator`.
+	ElementFormat string
+
+	// AllowedVerbs restricts format strings to the listed verbs, e.g. "sd" allows only %s and %d.
+	// `Parse` and `FormatAST` fail on other verbs. Empty allows all verbs. %% is always allowed.
+	AllowedVerbs string
+
+	// EastAsianWidth pads values to a width in terminal columns, counting wide and fullwidth East Asian characters
+	// as two columns, e.g. "%6s" pads "日本" with two spaces. It does not change truncation by precision, which counts runes,
+	// whereas %C cells are truncated to their width in columns.
+	// By default each rune counts as one.
+	EastAsianWidth bool
+
+	// Strict makes formatting fail if an argument is never used, e.g. Format("%2$s", "a", "b").
+	// By default unused arguments are ignored.
+	Strict bool
+
+	// JSONIndentTabs makes %j indent by one tab per level instead of by width spaces, e.g. %1j.
+	// %j without width stays compact.
+	JSONIndentTabs bool
+
+	// JSONNoEscapeHTML makes %j keep the characters <, > and & in strings as they are.
+	// By default they are escaped like by `json.Marshal`, e.g. "\u003cb\u003e", so that JSON is safe to embed in HTML.
+	JSONNoEscapeHTML bool
+
+	// Lenient makes formatting render a marker in place of a placeholder that fails because of its argument,
+	// like `fmt` does, instead of failing altogether, e.g. %!d(string=abc) for a type mismatch,
+	// %!d(MISSING) for a missing argument, %!s(BADKEY) for a key that cannot be looked up
+	// and %!(BADWIDTH) or %!(BADPREC) for an invalid * width or precision.
+	// With `Strict` unused arguments are appended as %!(EXTRA int=1). Invalid format strings, verbs rejected by
+	// `AllowedVerbs` and exceeding `MaxOutputBytes` are still errors.
+	Lenient bool
+
+	// CellOverflow is how the C verb fits values that are wider than the cell.
+	CellOverflow CellOverflow
+
+	parseBoolStrings bool            // see `FormatStrings`
+	ctx              context.Context // see `FormatContext`
+	padLimit         int             // see `alignedPad`
+}
+
+// Alignments of padded values.
+const (
+	AlignRight  = ""
+	AlignLeft   = "-"
+	AlignCenter = "="
+)
+
+// DefaultMaxPrecision is the largest precision of floating point numbers unless configured otherwise.
+const DefaultMaxPrecision = 100
+
+var defaultFormatter = &Formatter{}
+
+var errOutputLimit = errors.New("output limit exceeded")
+
+// Format formats a string based on the instructions in `format` using the values in `args`.
+// See the package level `Format` for the format specification.
+func (f *Formatter) Format(format string, args ...interface{}) (string, error) {
+	// constant strings, e.g. log messages without arguments, are returned without parsing and copying them
+	if isConstant(format) && f.MaxOutputBytes <= 0 && (!f.Strict || len(args) == 0) {
+		return format, nil
+	}
+	ast, err := f.Parse(format)
+	if err != nil {
+		return "", err
+	}
+	return f.FormatAST(ast, args...)
+}
+
+// Vformat is like `Format` with the arguments given as a slice. See the package level `Vformat`.
+func (f *Formatter) Vformat(format string, args []interface{}) (string, error) {
+	return f.Format(format, args...)
+}
+
+// Fformat formats like `Format` but writes the result to `w`. See the package level `Fformat`.
+func (f *Formatter) Fformat(w io.Writer, format string, args ...interface{}) (int, error) {
+	ast, err := f.Parse(format)
+	if err != nil {
+		return 0, err
+	}
+	return f.fformat(w, ast, args)
+}
+
+// FformatAST formats an abstract syntax tree returned by `Parse` to `w`. See the package level `Fformat`.
+func (f *Formatter) FformatAST(w io.Writer, ast AST, args ...interface{}) (int, error) {
+	return f.fformat(w, ast, args)
+}
+
+// AppendFormat formats like `Format` and appends the result to `dst`. See the package level `AppendFormat`.
+func (f *Formatter) AppendFormat(dst []byte, format string, args ...interface{}) ([]byte, error) {
+	ast, err := f.Parse(format)
+	if err != nil {
+		return dst, err
+	}
+	return f.AppendFormatAST(dst, ast, args...)
+}
+
+// AppendFormatAST formats an abstract syntax tree returned by `Parse` and appends the result to `dst`.
+// See the package level `AppendFormat`.
+func (f *Formatter) AppendFormatAST(dst []byte, ast AST, args ...interface{}) ([]byte, error) {
+	output := appendWriter(dst)
+	if _, err := f.fformat(&output, ast, args); err != nil {
+		return dst, err
+	}
+	return output, nil
+}
+
+// FormatStrings is like `Format` for arguments given as strings. See `FormatStrings`.
+func (f *Formatter) FormatStrings(format string, args ...string) (string, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+
+	sf := *f
+	sf.parseBoolStrings = true
+	return sf.Format(format, values...)
+}
+
+// FormatAST formats an abstract syntax tree returned by `Parse`.
+func (f *Formatter) FormatAST(ast AST, args ...interface{}) (string, error) {
+	output := strings.Builder{}
+	if _, err := f.fformat(&output, ast, args); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// fformat formats an abstract syntax tree to `w` and returns the number of bytes written.
+func (f *Formatter) fformat(w io.Writer, ast AST, args []interface{}) (int, error) {
+	if f.Strict && !f.Lenient {
+		if unused := f.unusedArgs(ast, args); len(unused) > 0 {
+			return 0, errorf(ErrUnusedArg, "[sprintf] argument %d is never used", unused[0]).at("", unused[0])
+		}
+	}
+
+	cursor := 0
+
+	output := &limitedWriter{w: w, limit: f.MaxOutputBytes}
+
+	// text preceding a placeholder is held back as it is omitted along with an empty `?` placeholder
+	pending := ""
+	omitText := false
+
+	// inline writes the marker of a failed placeholder in `Lenient` mode, see `errorMarker`
+	inline := func(marker string) error {
+		_, err := output.WriteString(pending + marker)
+		pending, omitText = "", false
+		return err
+	}
+
+	for _, node := range ast {
+		if err := f.canceled(); err != nil {
+			return output.n, err
+		}
+		if node.Text != "" {
+			if !omitText {
+				pending += node.Text
+			}
+			continue
+		}
+
+		var err error
+		if node.DynamicWidth {
+			if node, cursor, err = f.dynamicWidth(node, args, cursor); err != nil {
+				if !f.Lenient {
+					return output.n, err
+				}
+				if err = inline("%!(BADWIDTH)"); err != nil {
+					return f.outputLimitExceeded(output)
+				}
+				cursor++
+			}
+		}
+
+		if node.DynamicPrecision {
+			if node, cursor, err = f.dynamicPrecision(node, args, cursor); err != nil {
+				if !f.Lenient {
+					return output.n, err
+				}
+				if err = inline("%!(BADPREC)"); err != nil {
+					return f.outputLimitExceeded(output)
+				}
+				cursor++
+			}
+		}
+
+		argNo := f.argumentNo(node, cursor)
+		arg, nextCursor, err := f.argumentValue(node, args, cursor)
+		if node.HasDefault && (errors.Is(err, errMissingKey) || err == nil && isNull(arg)) {
+			arg, err, node.Layout = node.Default, nil, ""
+		}
+		if err != nil {
+			err = errorAt(err, node.Placeholder, argNo)
+			marker, ok := f.errorMarker(node, err, nil)
+			if !ok {
+				return output.n, err
+			}
+			if err = inline(marker); err != nil {
+				return f.outputLimitExceeded(output)
+			}
+			continue
+		}
+		cursor = nextCursor
+
+		if node.Omit != "" && isEmpty(arg) {
+			pending, omitText = "", true
+			continue
+		}
+
+		if node.Width > f.maxWidth() {
+			return output.n, errorf(ErrInvalidPlaceholder, "[sprintf] width %d exceeds the maximum of %d", node.Width, f.maxWidth()).at(node.Placeholder, 0)
+		}
+		if !f.verbAllowed(node.Type) {
+			return output.n, errorf(ErrVerbNotAllowed, "[sprintf] verb %q of %q is not allowed", node.Type, node.Placeholder).at(node.Placeholder, 0)
+		}
+
+		if node.Type == "j" {
+			if _, err = output.WriteString(pending); err != nil {
+				return f.outputLimitExceeded(output)
+			}
+			pending, omitText = "", false
+			if err = f.encodeJSON(output, node, arg); err != nil {
+				if output.err != nil {
+					return f.outputLimitExceeded(output)
+				}
+				if cerr := f.canceled(); cerr != nil {
+					return output.n, cerr
+				}
+				err = errorAt(err, node.Placeholder, argNo)
+				marker, ok := f.errorMarker(node, err, arg)
+				if !ok {
+					return output.n, err
+				}
+				if err = inline(marker); err != nil {
+					return f.outputLimitExceeded(output)
+				}
+			}
+			continue
+		}
+
+		// a padded value is at least `Width` bytes, only pad as far as the output fits, the write below fails at the limit
+		formatter := f
+		if fits := output.remaining() - len(pending); node.Width > fits {
+			limited := *f
+			limited.padLimit = fits + 1
+			if limited.padLimit < 1 {
+				limited.padLimit = 1
+			}
+			formatter = &limited
+		}
+
+		formatted, err := formatter.formatPlaceholder(node, arg)
+		if err != nil {
+			if cerr := f.canceled(); cerr != nil {
+				return output.n, cerr // e.g. while reading an io.Reader
+			}
+			err = errorAt(err, node.Placeholder, argNo)
+			marker, ok := f.errorMarker(node, err, arg)
+			if !ok {
+				return output.n, err
+			}
+			formatted = marker
+		}
+
+		if node.Omit != "" && formatted == "" {
+			pending, omitText = "", true
+			continue
+		}
+
+		if _, err = output.WriteString(pending + formatted); err != nil {
+			return f.outputLimitExceeded(output)
+		}
+		pending, omitText = "", false
+	}
+
+	if f.Strict && f.Lenient {
+		pending += extraMarker(f.unusedArgs(ast, args), args)
+	}
+	if _, err := output.WriteString(pending); err != nil {
+		return f.outputLimitExceeded(output)
+	}
+	return output.n, nil
+}
+
+// errorMarker returns the marker rendered instead of a placeholder that failed because of its argument
+// in `Lenient` mode, like `fmt` does, e.g. %!d(string=abc) for a type mismatch, %!d(MISSING) for a missing argument
+// and %!s(BADKEY) for a key that cannot be looked up. It returns false if the error must be returned instead.
+func (f *Formatter) errorMarker(ph ASTNode, err error, arg interface{}) (string, bool) {
+	var e *Error
+	if !f.Lenient || !errors.As(err, &e) {
+		return "", false
+	}
+	switch e.Kind {
+	case ErrTooFewArgs:
+		return "%!" + ph.Type + "(MISSING)", true
+	case ErrInvalidKey:
+		return "%!" + ph.Type + "(BADKEY)", true
+	case ErrTypeMismatch:
+		if arg == nil {
+			return "%!" + ph.Type + "(<nil>)", true
+		}
+		return fmt.Sprintf("%%!%s(%T=%v)", ph.Type, arg, arg), true
+	}
+	return "", false
+}
+
+// extraMarker returns the marker of unused arguments in `Lenient` mode, like `fmt` does, e.g. %!(EXTRA int=1, string=a).
+func extraMarker(unused []int, args []interface{}) string {
+	if len(unused) == 0 {
+		return ""
+	}
+	extra := make([]string, len(unused))
+	for i, argNo := range unused {
+		if arg := args[argNo-1]; arg == nil {
+			extra[i] = "<nil>"
+		} else {
+			extra[i] = fmt.Sprintf("%T=%v", arg, arg)
+		}
+	}
+	return "%!(EXTRA " + strings.Join(extra, ", ") + ")"
+}
+
+// argumentNo returns the 1-based index of the argument of a placeholder with the cursor at `cursor`.
+func (f *Formatter) argumentNo(ph ASTNode, cursor int) int {
+	switch {
+	case ph.Keys != nil && f.ContextArg > 0:
+		return f.ContextArg
+	case ph.Keys == nil && ph.ParamNo != 0:
+		return ph.ParamNo
+	}
+	return cursor + 1
+}
+
+// unusedArgs returns the 1-based indices of the arguments never used by the abstract syntax tree. See `Strict`.
+func (f *Formatter) unusedArgs(ast AST, args []interface{}) []int {
+	used := make([]bool, len(args))
+	ast.walkArgs(f.ContextArg, func(argNo int) {
+		if argNo <= len(used) {
+			used[argNo-1] = true
+		}
+	})
+	unused := []int{}
+	for i := range used {
+		if !used[i] {
+			unused = append(unused, i+1)
+		}
+	}
+	return unused
+}
+
+// dynamicWidth sets the * width of a placeholder to the argument at the cursor and advances the cursor.
+// A negative width left-aligns the placeholder.
+func (f *Formatter) dynamicWidth(ph ASTNode, args []interface{}, cursor int) (ASTNode, int, error) {
+	width, err := intArgument(args, cursor)
+	if err != nil {
+		return ph, cursor, wrapf(kindOf(err, ErrTypeMismatch), err, "[sprintf] failed to read width of %q: %v", ph.Placeholder, err).at(ph.Placeholder, cursor+1)
+	}
+	if width < 0 {
+		width, ph.Align = -width, AlignLeft
+	}
+	ph.Width = width
+	return ph, cursor + 1, nil
+}
+
+// dynamicPrecision sets the .* precision of a placeholder to the argument at the cursor and advances the cursor.
+// A negative precision is ignored.
+func (f *Formatter) dynamicPrecision(ph ASTNode, args []interface{}, cursor int) (ASTNode, int, error) {
+	precision, err := intArgument(args, cursor)
+	if err != nil {
+		return ph, cursor, wrapf(kindOf(err, ErrTypeMismatch), err, "[sprintf] failed to read precision of %q: %v", ph.Placeholder, err).at(ph.Placeholder, cursor+1)
+	}
+	if precision > f.maxWidth() {
+		return ph, cursor, errorf(ErrInvalidPlaceholder, "[sprintf] precision %d exceeds the maximum of %d", precision, f.maxWidth()).at(ph.Placeholder, cursor+1)
+	}
+	ph.Precision = ""
+	if precision >= 0 {
+		ph.Precision = strconv.Itoa(precision)
+	}
+	return ph, cursor + 1, nil
+}
+
+// intArgument returns the integer argument at `index`.
+func intArgument(args []interface{}, index int) (int, error) {
+	if index < 0 || index >= len(args) {
+		return 0, errorf(ErrTooFewArgs, "argument index %d is out of range", index+1)
+	}
+	vv := reflect.ValueOf(args[index])
+	switch vv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(vv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(vv.Uint()), nil
+	}
+	return 0, errorf(ErrTypeMismatch, "expecting integer but found %T", args[index])
+}
+
+// encodeJSON writes the JSON of a %j placeholder to `w` while encoding, so that `MaxOutputBytes` applies to it.
+func (f *Formatter) encodeJSON(w io.Writer, ph ASTNode, value interface{}) error {
+	value, text, isText, err := f.placeholderValue(ph, value)
+	if err != nil {
+		return err
+	}
+	if isText {
+		_, err = io.WriteString(w, text)
+		return err
+	}
+	if err = f.writeJSON(w, value, ph.Width); err != nil {
+		return wrapf(ErrTypeMismatch, err, "[sprintf] failed to format value %v as %q: %v", value, ph.Placeholder, err)
+	}
+	return nil
+}
+
+// writeJSON encodes a value with the width `width` to `w` without the trailing newline of `json.Encoder`.
+func (f *Formatter) writeJSON(w io.Writer, value interface{}, width int) error {
+	if addr, ok := netAddress(value); ok {
+		value = addr
+	}
+	return f.newJSONEncoder(&newlineTrimmer{w: w}, width).Encode(value)
+}
+
+// newJSONEncoder returns an encoder for %j with the width `width` writing to `w`.
+func (f *Formatter) newJSONEncoder(w io.Writer, width int) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if indent := f.jsonIndent(width); indent != "" {
+		encoder.SetIndent("", indent)
+	}
+	encoder.SetEscapeHTML(!f.JSONNoEscapeHTML)
+	return encoder
+}
+
+// jsonIndent returns the indentation per level of %j with the width `width`; empty for compact JSON.
+func (f *Formatter) jsonIndent(width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if f.JSONIndentTabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", width)
+}
+
+// floatPrecision applies `MaxPrecision` to the precision of a floating point number.
+// Its errors are wrapped by `formatPlaceholder`, which adds the placeholder.
+func (f *Formatter) floatPrecision(precision string) (string, error) {
+	if precision == "" {
+		return precision, nil
+	}
+	p, err := strconv.Atoi(precision)
+	if err != nil {
+		return "", wrapf(ErrInvalidPlaceholder, err, "failed to parse precision %q: %v", precision, err)
+	}
+
+	max := f.MaxPrecision
+	if max <= 0 {
+		max = DefaultMaxPrecision
+	}
+	if p <= max {
+		return precision, nil
+	}
+	if !f.ClampPrecision {
+		return "", errorf(ErrInvalidPlaceholder, "precision %d exceeds the maximum of %d", p, max)
+	}
+	return strconv.Itoa(max), nil
+}
+
+// align returns the alignment of a placeholder.
+func (f *Formatter) align(ph ASTNode) string {
+	if ph.Align != "" {
+		return ph.Align
+	}
+	return f.DefaultAlign
+}
+
+// padChar returns the padding of a placeholder, see `NoZeroPadStrings`.
+func (f *Formatter) padChar(ph ASTNode) string {
+	if f.NoZeroPadStrings && ph.Pad == "0" && (ph.Type == "s" || ph.Type == "q") {
+		return ""
+	}
+	return ph.Pad
+}
+
+func (f *Formatter) verbAllowed(typ string) bool {
+	return f.AllowedVerbs == "" || strings.Contains(f.AllowedVerbs, typ)
+}
+
+func (f *Formatter) listSeparator() string {
+	if f.ListSeparator == "" {
+		return ", "
+	}
+	return f.ListSeparator
+}
+
+func (f *Formatter) maxWidth() int {
+	if f.MaxWidth <= 0 {
+		return DefaultMaxWidth
+	}
+	return f.MaxWidth
+}
+
+// enumLabel returns the label of an integer value whose type is configured in `EnumLabels`.
+func (f *Formatter) enumLabel(value interface{}) (string, bool) {
+	labels, ok := f.EnumLabels[reflect.TypeOf(value)]
+	if !ok {
+		return "", false
+	}
+
+	var n int64
+	vv := reflect.ValueOf(value)
+	switch vv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = vv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = int64(vv.Uint())
+	default:
+		return "", false
+	}
+
+	if label, ok := labels[n]; ok {
+		return label, true
+	}
+	return strconv.FormatInt(n, 10), true
+}
+
+// read reads all of `r` as string. Reading stops after `MaxOutputBytes` or once enough bytes for `precision` were read.
+func (f *Formatter) read(r io.Reader, precision string) (string, error) {
+	limit := int64(-1)
+	if f.MaxOutputBytes > 0 {
+		limit = int64(f.MaxOutputBytes) + 1 // one more to trip the output limit
+	}
+	if precision != "" {
+		if p, err := strconv.Atoi(precision); err == nil && (limit < 0 || int64(p)*utf8.UTFMax < limit) {
+			limit = int64(p) * utf8.UTFMax
+		}
+	}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit)
+	}
+	if f.ctx != nil {
+		r = contextReader{f.ctx, r}
+	}
+
+	b, err := ioutil.ReadAll(r)
+	return string(b), err
+}
+
+// formatElements formats each element of a slice or array with `ElementFormat` and joins the results.
+func (f *Formatter) formatElements(list interface{}) (string, error) {
+	ast, err := f.Parse(f.ElementFormat)
+	if err != nil {
+		return "", err
+	}
+
+	vv := reflect.ValueOf(list)
+	elements := make([]string, 0, vv.Len())
+	for i := 0; i < vv.Len(); i++ {
+		element, err := f.FormatAST(ast, vv.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		elements = append(elements, element)
+	}
+	return strings.Join(elements, f.listSeparator()), nil
+}
+
+// outputLimitExceeded ends the output after a failed write to `output`.
+func (f *Formatter) outputLimitExceeded(output *limitedWriter) (int, error) {
+	if output.err != errOutputLimit {
+		return output.n, output.err
+	}
+	if f.OutputLimitMarker == "" {
+		return output.n, errorf(ErrOutputLimit, "[sprintf] output exceeds the limit of %d bytes", f.MaxOutputBytes)
+	}
+	n, err := io.WriteString(output.w, f.OutputLimitMarker)
+	return output.n + n, err
+}
+
+// limitedWriter writes at most `limit` bytes to `w`.
+type limitedWriter struct {
+	w     io.Writer
+	limit int   // zero means no limit
+	n     int   // number of bytes written
+	err   error // error of the first failed write
+}
+
+func (lw *limitedWriter) remaining() int {
+	if lw.limit <= 0 {
+		return int(^uint(0) >> 1)
+	}
+	return lw.limit - lw.n
+}
+
+// Write writes as much of `p` as fits and fails with `errOutputLimit` if not all of it did.
+// A short write of the underlying writer fails with `io.ErrShortWrite`.
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.err != nil {
+		return 0, lw.err
+	}
+
+	var limitErr error
+	if remaining := lw.remaining(); len(p) > remaining {
+		p, limitErr = p[:remaining], errOutputLimit
+	}
+
+	n, err := lw.w.Write(p)
+	lw.n += n
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		err = limitErr
+	}
+	lw.err = err
+	return n, err
+}
+
+func (lw *limitedWriter) WriteString(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return lw.Write([]byte(s))
+}
+
+// appendWriter appends to a byte slice.
+type appendWriter []byte
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+
+// newlineTrimmer drops the newline `json.Encoder` writes after each value.
+// It holds back a trailing newline of each write until more output follows.
+type newlineTrimmer struct {
+	w       io.Writer
+	newline bool
+}
+
+func (t *newlineTrimmer) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if t.newline {
+		if _, err := t.w.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		t.newline = false
+	}
+
+	n := len(p)
+	if p[n-1] == '\n' {
+		p, t.newline = p[:n-1], true
+	}
+	written, err := t.w.Write(p)
+	if written < len(p) || err != nil {
+		return written, err
+	}
+	return n, nil
+}
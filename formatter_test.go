@@ -0,0 +1,787 @@
+package sprintfjs_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+type formatterTestcase struct {
+	Expected string
+	Format   string
+	Args     []interface{}
+}
+
+func ftc(expected, format string, args ...interface{}) formatterTestcase {
+	return formatterTestcase{expected, format, args}
+}
+
+func runFormatterTests(t *testing.T, f *sprintfjs.Formatter, testcases []formatterTestcase) {
+	t.Helper()
+	for i := range testcases {
+		tc := testcases[i]
+		t.Run(
+			fmt.Sprintf("%s(%s)", tc.Expected, tc.Format),
+			func(t *testing.T) {
+				actual, err := f.Format(tc.Format, tc.Args...)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				if tc.Expected != actual {
+					t.Fatalf("expected %q had %q", tc.Expected, actual)
+				}
+			})
+	}
+}
+
+func TestFormatterDereferencePointers(t *testing.T) {
+	i := 65
+	f := 2.5
+	s := "x"
+	b := true
+	m := map[string]interface{}{"foo": "bar"}
+	pi := &i
+
+	var nilInt *int
+	var nilString *string
+
+	formatter := &sprintfjs.Formatter{DereferencePointers: true, NilText: "null"}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`1000001`, `%b`, &i),
+		ftc(`A`, `%c`, &i),
+		ftc(`65`, `%d`, &i),
+		ftc(`65`, `%i`, &i),
+		ftc(`65`, `%d`, &pi),
+		ftc(`2.5e+0`, `%e`, &f),
+		ftc(`2.5`, `%f`, &f),
+		ftc(`2.5`, `%g`, &f),
+		ftc(`101`, `%o`, &i),
+		ftc(`x`, `%s`, &s),
+		ftc(`true`, `%t`, &b),
+		ftc(`number`, `%T`, &i),
+		ftc(`65`, `%u`, &i),
+		ftc(`x`, `%v`, &s),
+		ftc(`41`, `%x`, &i),
+		ftc(`41`, `%X`, &i),
+		ftc(`{"foo":"bar"}`, `%j`, &m),
+
+		ftc(`null`, `%d`, nilInt),
+		ftc(`null`, `%s`, nilString),
+		ftc(`null`, `%t`, nilInt),
+		ftc(`null`, `%v`, nilInt),
+		ftc(`null`, `%j`, nilInt),
+		ftc(`  null`, `%6d`, nilInt),
+	})
+}
+
+func TestFormatterNilPointerWithoutNilText(t *testing.T) {
+	var nilInt *int
+	formatter := &sprintfjs.Formatter{DereferencePointers: true}
+	if _, err := formatter.Format(`%d`, nilInt); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFormatterKeepsPointers(t *testing.T) {
+	s := "x"
+	actual, err := (&sprintfjs.Formatter{}).Format(`%s`, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual == "x" {
+		t.Fatalf("expected pointer not to be dereferenced")
+	}
+}
+
+func TestFormatterMaxOutputBytes(t *testing.T) {
+	formatter := &sprintfjs.Formatter{MaxOutputBytes: 16}
+
+	large := map[string]interface{}{"items": make([]int, 100)}
+
+	for _, format := range []string{`%1000s`, `%2$j`, `%s %s`} {
+		if _, err := formatter.Format(format, "0123456789", large); err == nil {
+			t.Errorf("expected %q to exceed the output limit", format)
+		}
+	}
+
+	actual, err := formatter.Format(`%5s|%-9s|`, "abc", "defghi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "  abc|defghi   |"; expected != actual {
+		t.Fatalf("expected %q had %q", expected, actual)
+	}
+}
+
+func TestFormatterMaxOutputBytesTruncatesPadding(t *testing.T) {
+	formats := []string{`ab %1000s`, `ab %-1000s`, `ab %=1000s`, `ab %01000d`, `ab %+01000d`, `ab %#01000x`, `ab %'•=1000s`, `%1000C`}
+	for _, format := range formats {
+		full, err := sprintfjs.Format(format, 42)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, limit := range []int{1, 2, 3, 4, 5, 10, 600} {
+			formatter := &sprintfjs.Formatter{MaxOutputBytes: limit, OutputLimitMarker: "..."}
+			if actual, _ := formatter.Format(format, 42); actual != full[:limit]+"..." {
+				t.Errorf("expected %q limited to %d bytes to yield %q, had %q", format, limit, full[:limit]+"...", actual)
+			}
+		}
+	}
+}
+
+func TestFormatterOutputLimitMarker(t *testing.T) {
+	formatter := &sprintfjs.Formatter{MaxOutputBytes: 8, OutputLimitMarker: "..."}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`Hello wo...`, `Hello %s!`, "world"),
+		ftc(`Hello   ...`, `Hello %1000s!`, "world"),
+		ftc(`{"items"...`, `%j`, map[string]interface{}{"items": []int{1, 2, 3}}),
+		ftc(`Hello!`, `Hello!`),
+	})
+}
+
+func TestFormatterMaxWidth(t *testing.T) {
+	for _, format := range []string{`%99999999999999d`, `%99999999999999999999999d`, `%.2000000f`, `%2000000j`} {
+		if _, err := sprintfjs.Parse(format); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+
+	formatter := &sprintfjs.Formatter{MaxWidth: 10}
+	if _, err := formatter.Format(`%11s`, "x"); err == nil {
+		t.Errorf("expected width 11 to fail")
+	}
+	if _, err := formatter.FormatAST(sprintfjs.AST{{Placeholder: "%11s", Type: "s", Width: 11}}, "x"); err == nil {
+		t.Errorf("expected width 11 to fail")
+	}
+	if _, err := formatter.Format(`%10s`, "x"); err != nil {
+		t.Errorf("expected width 10 to pass, had %v", err)
+	}
+}
+
+type status int
+
+type color uint8
+
+func TestFormatterEnumLabels(t *testing.T) {
+	formatter := &sprintfjs.Formatter{
+		EnumLabels: map[reflect.Type]map[int64]string{
+			reflect.TypeOf(status(0)): {0: "pending", 1: "active"},
+			reflect.TypeOf(color(0)):  {0: "red", 1: "green"},
+		},
+	}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`active`, `%s`, status(1)),
+		ftc(`green`, `%s`, color(1)),
+		ftc(`42`, `%s`, status(42)),
+		ftc(`  pending`, `%9s`, status(0)),
+		ftc(`pen`, `%.3s`, status(0)),
+		ftc(`1`, `%s`, "1"),
+	})
+}
+
+func TestFormatterOctalPrefix0o(t *testing.T) {
+	formatter := &sprintfjs.Formatter{OctalPrefix0o: true}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`0o10`, `%#o`, 8),
+		ftc(`0o0`, `%#o`, 0),
+		ftc(`10`, `%o`, 8),
+		ftc(` 0o10`, `%#5o`, 8),
+		ftc(`0o010`, `%#05o`, 8),
+		ftc(`0o10 `, `%#-5o`, 8),
+	})
+}
+
+func TestFormatterBoolAsNumber(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{BoolAsNumber: true}, []formatterTestcase{
+		ftc(`1`, `%s`, true),
+		ftc(`0`, `%s`, false),
+		ftc(`1`, `%v`, true),
+		ftc(`0`, `%v`, false),
+		ftc(`1`, `%d`, true),
+		ftc(`0`, `%d`, false),
+		ftc(`1,0,text`, `%s,%d,%s`, true, false, "text"),
+		ftc(`true`, `%t`, true),
+		ftc(`boolean`, `%T`, true),
+		ftc(`false`, `%j`, false),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`true`, `%v`, true),
+		ftc(`false`, `%v`, false),
+		ftc(`1`, `%d`, true),
+		ftc(`0`, `%d`, false),
+	})
+}
+
+func TestFormatterMaxPrecision(t *testing.T) {
+	if _, err := sprintfjs.Format(`%.1000f`, 1.0); err == nil {
+		t.Errorf("expected precision 1000 to fail")
+	}
+	_, err := (&sprintfjs.Formatter{MaxPrecision: 3}).Format(`%.4f`, 1.0)
+	if expected := `[sprintf] failed to format value 1 as "%.4f": precision 4 exceeds the maximum of 3`; err == nil || err.Error() != expected {
+		t.Errorf("expected %q, had %v", expected, err)
+	}
+	if !errors.Is(err, sprintfjs.ErrInvalidPlaceholder) {
+		t.Errorf("expected an invalid placeholder, had %v", err)
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{MaxPrecision: 3, ClampPrecision: true}, []formatterTestcase{
+		ftc(`3.142`, `%.10f`, 3.14159265),
+		ftc(`3.14`, `%.2f`, 3.14159265),
+		ftc(`3.142e+0`, `%.1000e`, 3.14159265),
+		ftc(`3.14`, `%.1000g`, 3.14159265),
+		ftc(`3.14159265`, `%.10s`, "3.14159265"),
+	})
+
+	actual, err := (&sprintfjs.Formatter{ClampPrecision: true}).Format(`%.1000f`, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 2+sprintfjs.DefaultMaxPrecision {
+		t.Fatalf("expected precision to be clamped had %d digits", len(actual)-2)
+	}
+}
+
+func TestFormatterDefaultAlign(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{DefaultAlign: sprintfjs.AlignRight}, []formatterTestcase{
+		ftc(`    x`, `%5s`, "x"),
+		ftc(`x    `, `%-5s`, "x"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{DefaultAlign: sprintfjs.AlignLeft}, []formatterTestcase{
+		ftc(`x    `, `%5s`, "x"),
+		ftc(`x    `, `%-5s`, "x"),
+		ftc(`-3  |`, `%4d|`, -3),
+		ftc(`x`, `%s`, "x"),
+	})
+}
+
+func TestFormatterCollapseWhitespace(t *testing.T) {
+	input := "  Hello,\n\tworld!\r\n  How  are you?  \n"
+
+	runFormatterTests(t, &sprintfjs.Formatter{CollapseWhitespace: true}, []formatterTestcase{
+		ftc(`Hello, world! How are you?`, `%s`, input),
+		ftc(`Hello, wo`, `%.9s`, input),
+		ftc(`[a b]`, `[%s]`, "a b"),
+		ftc(`[ab]`, `[%s]`, "ab"),
+		ftc(`[]`, `[%s]`, " \t\n "),
+		ftc(`[  a b]`, `[%5s]`, " a \n b "),
+		ftc("a\nb", "a\n%sb", "\n"),
+	})
+}
+
+func TestFormatterEngineeringNotation(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{EngineeringNotation: true}, []formatterTestcase{
+		ftc(`12.3e+3`, `%e`, 12300),
+		ftc(`1.23e+3`, `%e`, 1230),
+		ftc(`123e+3`, `%e`, 123000),
+		ftc(`1e+6`, `%e`, 1e6),
+		ftc(`4.7e-6`, `%e`, 4.7e-6),
+		ftc(`470e-9`, `%e`, 4.7e-7),
+		ftc(`47e-9`, `%e`, 4.7e-8),
+		ftc(`1.5e+0`, `%e`, 1.5),
+		ftc(`0e+0`, `%e`, 0),
+		ftc(`-12.3e+3`, `%e`, -12300),
+		ftc(`+12.3e+3`, `%+e`, 12300),
+		ftc(`12.35e+3`, `%.2e`, 12345.6),
+		ftc(`1.0e+3`, `%.1e`, 999.96),
+		ftc(`12.3e+24`, `%e`, 1.23e25),
+		ftc(`  1.5e+3`, `%8e`, 1500),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`1.23e+04`, `%e`, 12300),
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("broken") }
+
+func TestFormatterReader(t *testing.T) {
+	reader := func(s string) func() io.Reader {
+		return func() io.Reader { return strings.NewReader(s) }
+	}
+	bytesReader := func(s string) func() io.Reader {
+		return func() io.Reader { return bytes.NewReader([]byte(s)) }
+	}
+
+	type testcase struct {
+		Expected  string
+		Format    string
+		Reader    func() io.Reader
+		Formatter *sprintfjs.Formatter
+	}
+	testcases := []testcase{
+		{`Hello world!`, `Hello %s!`, reader("world"), &sprintfjs.Formatter{}},
+		{`Hello world!`, `Hello %s!`, bytesReader("world"), &sprintfjs.Formatter{}},
+		{`Hello wor!`, `Hello %.3s!`, reader("world"), &sprintfjs.Formatter{}},
+		{`Hello wor!`, `Hello %.3s!`, bytesReader("world"), &sprintfjs.Formatter{}},
+		{`Hello   world!`, `Hello %7s!`, bytesReader("world"), &sprintfjs.Formatter{}},
+		{`Hello world!`, `Hello %s!`, bytesReader("world"), &sprintfjs.Formatter{DereferencePointers: true}},
+		{`Hello wo...`, `Hello %s!`, reader(strings.Repeat("world", 1000)), &sprintfjs.Formatter{MaxOutputBytes: 8, OutputLimitMarker: "..."}},
+	}
+	for _, tc := range testcases {
+		actual, err := tc.Formatter.Format(tc.Format, tc.Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.Expected != actual {
+			t.Errorf("expected %q had %q", tc.Expected, actual)
+		}
+	}
+
+	if _, err := sprintfjs.Format(`%s`, errReader{}); err == nil {
+		t.Errorf("expected a read error")
+	}
+
+	r := strings.NewReader("world")
+	sprintfjs.Format(`%s`, r)
+	if r.Len() != 0 {
+		t.Errorf("expected the reader to be consumed")
+	}
+}
+
+func TestFormatterElementFormat(t *testing.T) {
+	type item struct {
+		Name  string
+		Price float64
+	}
+	items := []item{{"apple", 0.5}, {"pear", 0.75}}
+
+	runFormatterTests(t, &sprintfjs.Formatter{ElementFormat: "%.1f"}, []formatterTestcase{
+		ftc(`1.5, 2.2`, `%v`, []float64{1.5, 2.25}),
+		ftc(`1.0, 2.0, 3.0`, `%v`, [3]int{1, 2, 3}),
+		ftc(``, `%v`, []float64{}),
+		ftc(`1.5, `, `%.5v`, []float64{1.5, 2.25}),
+		ftc(`[1.5,2.25]`, `%j`, []float64{1.5, 2.25}),
+		ftc(`[98 121 116 101 115]`, `%v`, []byte("bytes")),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ElementFormat: "<%v>", ListSeparator: " "}, []formatterTestcase{
+		ftc(`<{apple 0.5}> <{pear 0.75}>`, `%v`, items),
+		ftc(`<<1> <2>> <<3>>`, `%v`, [][]int{{1, 2}, {3}}),
+	})
+
+	if _, err := (&sprintfjs.Formatter{ElementFormat: "%d"}).Format(`%v`, []string{"x"}); err == nil {
+		t.Errorf("expected an element format error")
+	}
+}
+
+func TestFormatterNullValues(t *testing.T) {
+	var nilInt *int
+	invalid := sql.NullString{}
+
+	runFormatterTests(t, &sprintfjs.Formatter{NilText: "NULL"}, []formatterTestcase{
+		ftc(`NULL`, `%s`, nil),
+		ftc(`NULL`, `%s`, nilInt),
+		ftc(`NULL`, `%s`, invalid),
+		ftc(`  NULL`, `%6d`, nil),
+		ftc(`NULL`, `%d`, nilInt),
+		ftc(`NULL`, `%d`, invalid),
+		ftc(`null`, `%j`, nil),
+		ftc(`null`, `%j`, nilInt),
+		ftc(`null`, `%j`, invalid),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{NilText: "-", NullAsZero: true}, []formatterTestcase{
+		ftc(`-`, `%s`, invalid),
+		ftc(`00000`, `%05d`, nil),
+		ftc(`0.00`, `%.2f`, nilInt),
+		ftc(`0`, `%x`, sql.NullInt64{}),
+		ftc(`null`, `%j`, sql.NullInt64{}),
+	})
+}
+
+func TestFormatterNullOmit(t *testing.T) {
+	actual, err := (&sprintfjs.Formatter{}).Format(`%s, %s?, %s`, "a", sql.NullString{}, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != "ac" {
+		t.Fatalf("expected invalid sql.NullString to be omitted, had %q", actual)
+	}
+}
+
+func TestFormatterAllowedVerbs(t *testing.T) {
+	formatter := &sprintfjs.Formatter{AllowedVerbs: "sd"}
+
+	if actual, err := formatter.Format(`%s scored %d%%`, "alice", 42); err != nil || actual != "alice scored 42%" {
+		t.Fatalf("expected allowed verbs to format, had %q, %v", actual, err)
+	}
+	if _, err := formatter.Parse(`%s scored %d: %j`); err == nil {
+		t.Fatal("expected Parse to reject %j")
+	}
+
+	ast, err := sprintfjs.Parse(`%s scored %d: %j`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := formatter.FormatAST(ast, "alice", 42, map[string]int{}); err == nil {
+		t.Fatal("expected FormatAST to reject %j")
+	}
+}
+
+func TestFormatterStreamsJSON(t *testing.T) {
+	large := map[string]interface{}{"items": make([]int, 10000), "html": "<a & b>", "nested": []interface{}{nil, 1.5, "x\n"}}
+	values := []interface{}{large, nil, "text", []string{}, json.RawMessage(`{"raw" : true}`)}
+
+	for _, value := range values {
+		for _, indent := range []int{0, 2} {
+			var expected []byte
+			var err error
+			if indent > 0 {
+				expected, err = json.MarshalIndent(value, "", strings.Repeat(" ", indent))
+			} else {
+				expected, err = json.Marshal(value)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			format := `<%` + fmt.Sprint(indent) + `j>`
+			actual, err := sprintfjs.Format(format, value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if "<"+string(expected)+">" != actual {
+				t.Errorf("expected JSON to match json.Marshal for %T with indent %d", value, indent)
+			}
+
+			streamed := bytes.Buffer{}
+			if _, err := sprintfjs.Fformat(&streamed, format, value); err != nil {
+				t.Fatal(err)
+			}
+			if "<"+string(expected)+">" != streamed.String() {
+				t.Errorf("expected streamed JSON to match json.Marshal for %T with indent %d", value, indent)
+			}
+		}
+	}
+
+	if _, err := sprintfjs.Format(`%j`, make(chan int)); err == nil {
+		t.Error("expected unsupported type to fail")
+	}
+}
+
+type compactMarshaler struct{}
+
+func (compactMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"name":"alice", "tags" : ["a","b"]}`), nil
+}
+
+func TestFormatJSONMarshaler(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`{"name":"alice","tags":["a","b"]}`, `%j`, compactMarshaler{}),
+		ftc("{\n  \"name\": \"alice\",\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}", `%2j`, compactMarshaler{}),
+		ftc("[\n {\n  \"name\": \"alice\",\n  \"tags\": [\n   \"a\",\n   \"b\"\n  ]\n }\n]", `%1j`, []compactMarshaler{{}}),
+	})
+
+	ast, err := sprintfjs.Parse(`%2j`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if _, err := sprintfjs.FformatAST(&buf, ast, compactMarshaler{}); err != nil {
+		t.Fatal(err)
+	}
+	if actual, _ := sprintfjs.Format(`%2j`, compactMarshaler{}); buf.String() != actual {
+		t.Errorf("expected streamed and buffered output to match, had %q and %q", buf.String(), actual)
+	}
+}
+
+func TestFormatterJSONIndentTabs(t *testing.T) {
+	value := map[string]interface{}{"a": []int{1}}
+
+	runFormatterTests(t, &sprintfjs.Formatter{JSONIndentTabs: true}, []formatterTestcase{
+		ftc("{\n\t\"a\": [\n\t\t1\n\t]\n}", `%1j`, value),
+		ftc("{\n\t\"a\": [\n\t\t1\n\t]\n}", `%4j`, value),
+		ftc(`{"a":[1]}`, `%j`, value),
+		ftc(`{"name":"alice","tags":["a","b"]}`, `%j`, compactMarshaler{}),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc("{\n  \"a\": [\n    1\n  ]\n}", `%2j`, value),
+	})
+
+	formatter := &sprintfjs.Formatter{JSONIndentTabs: true}
+	ast, err := formatter.Parse(`%2j`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if _, err := formatter.FformatAST(&buf, ast, value); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{\n\t\"a\": [\n\t\t1\n\t]\n}"; buf.String() != expected {
+		t.Errorf("expected %q, had %q", expected, buf.String())
+	}
+}
+
+func TestFormatterJSONNoEscapeHTML(t *testing.T) {
+	value := map[string]string{"html": "<a & b>"}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`{"html":"\u003ca \u0026 b\u003e"}`, `%j`, value),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{JSONNoEscapeHTML: true}, []formatterTestcase{
+		ftc(`{"html":"<a & b>"}`, `%j`, value),
+		ftc("{\n  \"html\": \"<a & b>\"\n}", `%2j`, value),
+		ftc(`"<b>"`, `%j`, "<b>"),
+	})
+
+	formatter := &sprintfjs.Formatter{JSONNoEscapeHTML: true}
+	ast, err := formatter.Parse(`%j`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if _, err := formatter.FformatAST(&buf, ast, value); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"html":"<a & b>"}`; buf.String() != expected {
+		t.Errorf("expected %q, had %q", expected, buf.String())
+	}
+}
+
+type shortWriter struct{ limit int }
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		p = p[:w.limit]
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestFformat(t *testing.T) {
+	buf := bytes.Buffer{}
+	n, err := sprintfjs.Fformat(&buf, `%s has %05.1f%% of %j`, "alice", 12.345, map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `alice has 012.3% of {"a":1}`; buf.String() != expected || n != len(expected) {
+		t.Fatalf("expected %q had %q (%d bytes)", expected, buf.String(), n)
+	}
+
+	buf.Reset()
+	n, err = (&sprintfjs.Formatter{MaxOutputBytes: 4, OutputLimitMarker: "..."}).FformatAST(&buf, mustParse(t, `Hello %s`), "world")
+	if err != nil || buf.String() != "Hell..." || n != 7 {
+		t.Fatalf("expected limited output had %q (%d bytes), %v", buf.String(), n, err)
+	}
+
+	for _, format := range []string{`Hello %s!`, `Hello %j!`} {
+		n, err = sprintfjs.Fformat(&shortWriter{limit: 8}, format, "world")
+		if err != io.ErrShortWrite || n != 8 {
+			t.Errorf("expected a short write after 8 bytes of %q had %d bytes, %v", format, n, err)
+		}
+	}
+}
+
+func TestAppendFormat(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	for i, expected := range []string{"#0 a=1.50", "#1 a=3.00"} {
+		var err error
+		buf, err = sprintfjs.AppendFormat(buf[:0], `#%d %s=%.2f`, i, "a", 1.5*float64(i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != expected {
+			t.Fatalf("expected %q had %q", expected, buf)
+		}
+	}
+
+	prefix := []byte("log: ")
+	actual, err := sprintfjs.AppendFormatAST(prefix, mustParse(t, `%s`), "x")
+	if err != nil || string(actual) != "log: x" {
+		t.Fatalf("expected appended output had %q, %v", actual, err)
+	}
+
+	actual, err = sprintfjs.AppendFormat(prefix, `%s %d`, "x", "y")
+	if err == nil || string(actual) != "log: " {
+		t.Fatalf("expected dst unchanged on error had %q, %v", actual, err)
+	}
+}
+
+func TestFormatterIndependentOptions(t *testing.T) {
+	const format = `%,.2f %s`
+	args := []interface{}{1234.5, strings.Repeat("x", 20)}
+
+	expected, err := sprintfjs.Format(format, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, err := (&sprintfjs.Formatter{}).Format(format, args...); err != nil || actual != expected {
+		t.Fatalf("expected the zero Formatter to format like Format, had %q, %v", actual, err)
+	}
+
+	german := &sprintfjs.Formatter{Locale: "de"}
+	limited := &sprintfjs.Formatter{MaxOutputBytes: 10, OutputLimitMarker: "…"}
+
+	wg := sync.WaitGroup{}
+	results := make([]string, 3)
+	for i, formatter := range []*sprintfjs.Formatter{german, limited, {}} {
+		wg.Add(1)
+		go func(i int, formatter *sprintfjs.Formatter) {
+			defer wg.Done()
+			results[i], _ = formatter.Format(format, args...)
+		}(i, formatter)
+	}
+	wg.Wait()
+
+	for i, expected := range []string{`1.234,50 xxxxxxxxxxxxxxxxxxxx`, `1,234.50 x…`, expected} {
+		if results[i] != expected {
+			t.Errorf("expected %q had %q", expected, results[i])
+		}
+	}
+}
+
+func TestFormatterStrict(t *testing.T) {
+	formatter := &sprintfjs.Formatter{Strict: true}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`a b`, `%s %s`, "a", "b"),
+		ftc(`b a b`, `%2$s %1$s %2$s`, "a", "b"),
+		ftc(`alice lives in Berlin`, `%s lives in %(city)s`, "alice", map[string]interface{}{"city": "Berlin"}),
+		ftc(`  3.1`, `%*.*f`, 5, 1, 3.14),
+		ftc(`ac`, `%s%s?%s`, "a", "", "c"),
+	})
+
+	testcases := []struct {
+		format string
+		args   []interface{}
+		unused string
+	}{
+		{`%s`, []interface{}{"a", "b"}, "argument 2"},
+		{`%2$s %3$s`, []interface{}{"a", "b", "c"}, "argument 1"},
+		{`no placeholders`, []interface{}{"a"}, "argument 1"},
+	}
+	for _, tc := range testcases {
+		_, err := formatter.Format(tc.format, tc.args...)
+		if err == nil || !strings.Contains(err.Error(), tc.unused) {
+			t.Errorf("expected %q to report %s as unused, had %v", tc.format, tc.unused, err)
+		}
+	}
+
+	if _, err := sprintfjs.Format(`%s`, "a", "b"); err != nil {
+		t.Errorf("expected unused arguments to be ignored by default, had %v", err)
+	}
+}
+
+func TestFormatterLenient(t *testing.T) {
+	formatter := &sprintfjs.Formatter{Lenient: true}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`a 42`, `%s %d`, "a", 42),
+		ftc(`count: %!d(string=abc)`, `count: %d`, "abc"),
+		ftc(`a %!s(MISSING) and %!d(MISSING)`, `%s %s and %d`, "a"),
+		ftc(`%!s(MISSING) a`, `%2$s %1$s`, "a"),
+		ftc(`Hello %!s(BADKEY)!`, `Hello %(user.name)s!`, map[string]interface{}{}),
+		ftc(`%!s(BADKEY) b`, `%(items[3])s %(items[1])s`, map[string]interface{}{"items": []string{"a", "b"}}),
+		ftc(`%!j(chan int=<nil>)`, `%j`, (chan int)(nil)),
+		ftc(`%!(BADWIDTH)a`, `%*s`, "x", "a"),
+		ftc(`%!(BADPREC)3.14`, `%.*f`, "x", 3.14),
+		ftc(`a %!d(string=b) c`, `%s %d?%s`, "a", "b", " c"),
+	})
+
+	if _, err := formatter.Format(`%y`, 1); !errors.Is(err, sprintfjs.ErrInvalidPlaceholder) {
+		t.Errorf("expected invalid format strings to fail, had %v", err)
+	}
+	if _, err := (&sprintfjs.Formatter{Lenient: true, AllowedVerbs: "s"}).Format(`%d`, 1); !errors.Is(err, sprintfjs.ErrVerbNotAllowed) {
+		t.Errorf("expected verbs that are not allowed to fail, had %v", err)
+	}
+
+	strict := &sprintfjs.Formatter{Lenient: true, Strict: true}
+	runFormatterTests(t, strict, []formatterTestcase{
+		ftc(`a`, `%s`, "a"),
+		ftc(`a%!(EXTRA int=1, <nil>)`, `%s`, "a", 1, nil),
+		ftc(`b%!(EXTRA string=a)`, `%2$s`, "a", "b"),
+	})
+}
+
+func TestFormatterNoNegativeZero(t *testing.T) {
+	formatter := &sprintfjs.Formatter{NoNegativeZero: true}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`0.0`, `%.1f`, -0.01),
+		ftc(`+0.0`, `%+.1f`, -0.01),
+		ftc(` 0.0`, `% .1f`, -0.01),
+		ftc(`00.00`, `%05.2f`, -0.001),
+		ftc(`0e+0`, `%.0e`, math.Copysign(0, -1)),
+		ftc(`0`, `%g`, math.Copysign(0, -1)),
+		ftc(`0`, `%.0f`, -0.4),
+		ftc(`-0.1`, `%.1f`, -0.06),
+		ftc(`-1`, `%.0f`, -0.6),
+		ftc(`-1e-04`, `%.0e`, -0.0001),
+		ftc(`-0`, `%d`, -0.4),
+	})
+
+	if s, _ := sprintfjs.Format(`%+.1f`, -0.01); s != `-0.0` {
+		t.Errorf("expected negative zero by default, had %q", s)
+	}
+}
+
+func TestFormatCase(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`HELLO WORLD`, `%^s`, "hello world"),
+		ftc(`hello world`, `%_s`, "Hello World"),
+		ftc(`Hello World`, `%~s`, "hello world"),
+		ftc(`Hello World`, `%~s`, "HELLO WORLD"),
+		ftc(`ÉCOLE`, `%^s`, "école"),
+		ftc(`HEL`, `%^.3s`, "hello"),
+		ftc(`  HELLO`, `%^7s`, "hello"),
+		ftc(`HELLO__`, `%^'_-7s`, "hello"),
+		ftc(`hello  `, `%_-7s`, "HELLO"),
+		ftc(`Hello, ALICE!`, `Hello, %(name)^s!`, map[string]string{"name": "alice"}),
+		ftc(`Ada Lovelace`, `%(user.name)~s`, map[string]interface{}{"user": map[string]string{"name": "ada lovelace"}}),
+		ftc(`GUEST`, `%(name:guest)^s`, map[string]string{}),
+		ftc(`42`, `%^d`, 42),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "nl"}, []formatterTestcase{
+		ftc(`IJsselmeer`, `%~s`, "ijsselmeer"),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "tr"}, []formatterTestcase{
+		ftc(`İSTANBUL İstanbul`, `%^s %~s`, "istanbul", "istanbul"),
+		ftc(`ıi`, `%_s`, "Iİ"),
+	})
+
+	ast := mustParse(t, `%^-10s`)
+	if ast[0].Case != "^" || ast[0].Align != "-" || ast[0].Width != 10 || ast.Format() != `%^-10s` {
+		t.Errorf("expected the case flag to be parsed, had %#v", ast[0])
+	}
+	if mustParse(t, `%_5s`)[0].Pad != "" || mustParse(t, `%'_5s`)[0].Case != "" {
+		t.Errorf("expected _ to be a case flag unless quoted as padding")
+	}
+}
+
+func TestFormatterNoZeroPadStrings(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{NoZeroPadStrings: true}, []formatterTestcase{
+		ftc(`    <`, `%05s`, "<"),
+		ftc(`<    `, `%-05s`, "<"),
+		ftc(`  "<"`, `%05q`, "<"),
+		ftc(`0000<`, `%'05s`, "<"),
+		ftc(`00042`, `%05d`, 42),
+		ftc(`003.5`, `%05.1f`, 3.5),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{NoZeroPadStrings: true, NilText: "null"}, []formatterTestcase{
+		ftc(` null`, `%05s`, nil),
+		ftc(`0null`, `%05d`, nil),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`0000<`, `%05s`, "<"),
+	})
+}
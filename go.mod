@@ -1,3 +1,5 @@
 module github.com/crazytyper/go-sprintfjs
 
-go 1.12
+go 1.13
+
+require golang.org/x/text v0.3.8
@@ -0,0 +1,63 @@
+package sprintfjs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"strconv"
+)
+
+// HashAlgorithm selects the hash function used by the `%H` verb.
+type HashAlgorithm int
+
+const (
+	// HashFNV is the 64-bit FNV-1a hash.
+	HashFNV HashAlgorithm = iota
+	// HashCRC32 is the IEEE CRC-32 checksum.
+	HashCRC32
+	// HashSHA256 is the SHA-256 hash.
+	HashSHA256
+)
+
+func (a HashAlgorithm) new() (hash.Hash, error) {
+	switch a {
+	case HashFNV:
+		return fnv.New64a(), nil
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("unknown hash algorithm %d", int(a))
+}
+
+// formatHash renders the hexadecimal digest of the bytes of a string or byte slice.
+// Other values are hashed in their default format.
+func (f *Formatter) formatHash(value interface{}, precision string) (string, error) {
+	h, err := f.HashAlgorithm.new()
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case string:
+		h.Write([]byte(v))
+	case []byte:
+		h.Write(v)
+	default:
+		fmt.Fprint(h, v)
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	if precision != "" {
+		width, err := strconv.Atoi(precision)
+		if err != nil {
+			return "", wrapf(ErrInvalidPlaceholder, err, "[sprintf] failed to parse precision %q: %v", precision, err)
+		}
+		digest = trim(digest, width)
+	}
+	return digest, nil
+}
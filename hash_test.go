@@ -0,0 +1,39 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatHash(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`779a65e7023cd2e7`, `%H`, "hello world"),
+		ftc(`779a65e7023cd2e7`, `%H`, []byte("hello world")),
+		ftc(`779a65e7`, `%.8H`, "hello world"),
+		ftc(`  779a`, `%6.4H`, "hello world"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{HashAlgorithm: sprintfjs.HashCRC32}, []formatterTestcase{
+		ftc(`0d4a1185`, `%H`, "hello world"),
+		ftc(`0d4a`, `%.4H`, "hello world"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{HashAlgorithm: sprintfjs.HashSHA256}, []formatterTestcase{
+		ftc(`b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9`, `%H`, "hello world"),
+		ftc(`b94d27b9`, `%.8H`, "hello world"),
+		ftc(`b94d27b9`, `%.8H`, []byte("hello world")),
+	})
+}
+
+func TestFormatHashIsDeterministic(t *testing.T) {
+	first, err := sprintfjs.Format(`%H`, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if next, _ := sprintfjs.Format(`%H`, 42); next != first {
+			t.Fatalf("expected %q had %q", first, next)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package sprintfjs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// wildcardKey maps the remaining keys over all elements of a slice.
+const wildcardKey = "[*]"
+
+// errMissingKey is the underlying error of keys that are missing or nil rather than invalid, see `ASTNode.Default`.
+var errMissingKey = errors.New("missing key")
+
+// lookup walks `keys` starting at `arg`. `path` is the full key path used in errors.
+// Index access works on slices and arrays of any element type, on maps with integer keys and on pointers to them.
+// A missing map key yields nil if it is the last key, and fails naming the missing segment otherwise.
+func (f *Formatter) lookup(arg interface{}, keys []string, path []string) (interface{}, error) {
+	for i, key := range keys {
+		if key == wildcardKey {
+			return f.lookupAll(arg, keys[i+1:], path)
+		}
+		if arg == nil {
+			return nil, wrapf(ErrInvalidKey, errMissingKey, "[sprintf] Cannot access property %q of nil in %q", key, keyPath(path))
+		}
+
+		var value interface{}
+		found, ok := false, false
+		if index, isIndex := indexKey(key); isIndex {
+			vv := reflect.ValueOf(arg)
+			for vv.Kind() == reflect.Ptr && !vv.IsNil() {
+				vv = vv.Elem()
+			}
+			switch vv.Kind() {
+			case reflect.Slice, reflect.Array:
+				if index >= vv.Len() {
+					return nil, wrapf(ErrInvalidKey, errMissingKey, "[sprintf] Index %d is out of range in %q, length is %d", index, keyPath(path), vv.Len())
+				}
+				value, found, ok = vv.Index(index).Interface(), true, true
+			case reflect.Map:
+				value, found, ok = mapIndex(vv, strconv.Itoa(index))
+			}
+			if !ok {
+				return nil, errorf(ErrInvalidKey, "[sprintf] Cannot access index %d in value of type %T", index, arg)
+			}
+		} else if value, found, ok = property(arg, key); !ok {
+			return nil, errorf(ErrInvalidKey, "[sprintf] Cannot access property %q in value of type %T", key, arg)
+		}
+
+		if !found && i < len(keys)-1 {
+			return nil, wrapf(ErrInvalidKey, errMissingKey, "[sprintf] Path %q not found at segment %q", keyPath(path), key)
+		}
+		arg = value
+	}
+	return arg, nil
+}
+
+// property returns the property `key` of a map, an `OrderedMap` or a struct. `found` is false for missing map keys.
+// `ok` is false if `arg` has no properties. Exported struct fields match by their json tag or case-insensitively by name.
+func property(arg interface{}, key string) (value interface{}, found bool, ok bool) {
+	if marg, ok := arg.(map[string]interface{}); ok {
+		value, found = marg[key]
+		return value, found, true
+	}
+	if oarg, ok := arg.(OrderedMap); ok {
+		value, found = oarg.Get(key)
+		return value, found, true
+	}
+
+	vv := reflect.ValueOf(arg)
+	for vv.Kind() == reflect.Ptr && !vv.IsNil() {
+		vv = vv.Elem()
+	}
+
+	switch vv.Kind() {
+	case reflect.Map:
+		return mapIndex(vv, key)
+	case reflect.Struct:
+		if i, ok := fieldIndex(vv.Type(), key); ok {
+			return vv.Field(i).Interface(), true, true
+		}
+	}
+	return nil, false, false
+}
+
+// mapIndex returns the value of `key` in a map with string, integer or interface keys, e.g. map[interface{}]interface{}
+// decoded from YAML. Integer keys match decimal numbers, e.g. "2". Interface keys match strings, then integers.
+func mapIndex(vv reflect.Value, key string) (value interface{}, found bool, ok bool) {
+	keyType := vv.Type().Key()
+	keys := []reflect.Value{}
+	switch keyType.Kind() {
+	case reflect.String:
+		keys = append(keys, reflect.ValueOf(key).Convert(keyType))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, false, false
+		}
+		k := reflect.New(keyType).Elem()
+		if k.OverflowInt(i) {
+			return nil, false, true
+		}
+		k.SetInt(i)
+		keys = append(keys, k)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return nil, false, false
+		}
+		k := reflect.New(keyType).Elem()
+		if k.OverflowUint(u) {
+			return nil, false, true
+		}
+		k.SetUint(u)
+		keys = append(keys, k)
+	case reflect.Interface:
+		if keyType.NumMethod() > 0 {
+			return nil, false, false
+		}
+		keys = append(keys, reflect.ValueOf(key))
+		if i, err := strconv.Atoi(key); err == nil {
+			keys = append(keys, reflect.ValueOf(i))
+		}
+	default:
+		return nil, false, false
+	}
+
+	for _, k := range keys {
+		if value := vv.MapIndex(k); value.IsValid() {
+			return value.Interface(), true, true
+		}
+	}
+	return nil, false, true
+}
+
+// fieldIndex returns the index of the exported struct field matching `key`.
+// A field whose json tag names `key` takes precedence over a field named `key`,
+// which takes precedence over a field whose name differs from `key` only in case.
+func fieldIndex(typ reflect.Type, key string) (int, bool) {
+	byName, byFoldedName := -1, -1
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		switch {
+		case tag == key:
+			return i, true
+		case field.Name == key && byName < 0:
+			byName = i
+		case strings.EqualFold(field.Name, key) && byFoldedName < 0:
+			byFoldedName = i
+		}
+	}
+	if byName >= 0 {
+		return byName, true
+	}
+	return byFoldedName, byFoldedName >= 0
+}
+
+// lookupAll walks `keys` starting at each element of the slice `arg` and joins the results.
+func (f *Formatter) lookupAll(arg interface{}, keys []string, path []string) (interface{}, error) {
+	vv := reflect.ValueOf(arg)
+	for vv.Kind() == reflect.Ptr && !vv.IsNil() {
+		vv = vv.Elem()
+	}
+	if vv.Kind() != reflect.Slice && vv.Kind() != reflect.Array {
+		return nil, errorf(ErrInvalidKey, "[sprintf] Cannot iterate value of type %T in %q", arg, keyPath(path))
+	}
+
+	values := make([]string, 0, vv.Len())
+	for i := 0; i < vv.Len(); i++ {
+		value, err := f.lookup(vv.Index(i).Interface(), keys, path)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, fmt.Sprint(value))
+	}
+	return strings.Join(values, f.listSeparator()), nil
+}
+
+// indexKey returns the index of an index access key, e.g. 2 for "[2]".
+func indexKey(key string) (int, bool) {
+	if !strings.HasPrefix(key, "[") || !strings.HasSuffix(key, "]") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(key[1 : len(key)-1])
+	return index, err == nil
+}
+
+// keyPath formats keys as written in a placeholder, e.g. "users[*].name".
+func keyPath(keys []string) string {
+	path := strings.Builder{}
+	for i, key := range keys {
+		if i > 0 && !strings.HasPrefix(key, "[") {
+			path.WriteString(".")
+		}
+		path.WriteString(key)
+	}
+	return path.String()
+}
@@ -0,0 +1,294 @@
+package sprintfjs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatWildcard(t *testing.T) {
+	users := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "alice"},
+			map[string]interface{}{"name": "bob"},
+			map[string]interface{}{"name": "carol"},
+		},
+		"groups": []map[string]interface{}{
+			{"members": []interface{}{map[string]interface{}{"name": "alice"}, map[string]interface{}{"name": "bob"}}},
+			{"members": []interface{}{map[string]interface{}{"name": "carol"}}},
+		},
+		"tags":  []string{"a", "b"},
+		"empty": []interface{}{},
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`alice, bob, carol`, `%(users[*].name)s`, users),
+		ftc(`alice, bob, carol`, `%(groups[*].members[*].name)s`, users),
+		ftc(`a, b`, `%(tags[*])s`, users),
+		ftc(`[]`, `[%(empty[*].name)s]`, users),
+		ftc(`alice, b`, `%(users[*].name).8s`, users),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ListSeparator: "|"}, []formatterTestcase{
+		ftc(`alice|bob|carol`, `%(users[*].name)s`, users),
+	})
+
+	for _, format := range []string{`%(users.name[*])s`, `%(users[*].name.first)s`} {
+		if _, err := sprintfjs.Format(format, users); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+}
+
+func TestFormatIndexAccess(t *testing.T) {
+	data := map[string]interface{}{
+		"matrix": []interface{}{
+			[]interface{}{1, 2, 3},
+			[]interface{}{4, 5, 6},
+		},
+		"grid": [][]int{{1, 2}, {3, 4}},
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`6`, `%(matrix[1][2])d`, data),
+		ftc(`1`, `%(matrix[0][0])d`, data),
+		ftc(`3`, `%(grid[1][0])d`, data),
+		ftc(`[4 5 6]`, `%(matrix[1])v`, data),
+		ftc(`1, 4`, `%(matrix[*][0])s`, data),
+	})
+
+	for _, format := range []string{`%(matrix[2][0])d`, `%(matrix[1][3])d`, `%(grid[0][2])d`, `%(matrix[0][0][0])d`} {
+		if _, err := sprintfjs.Format(format, data); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+
+	ast, err := sprintfjs.Parse(`%(matrix[1][2])d`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := ast[0].Keys; len(keys) != 3 || keys[1] != "[1]" || keys[2] != "[2]" {
+		t.Fatalf("unexpected keys %q", keys)
+	}
+}
+
+func TestFormatIndexedProperty(t *testing.T) {
+	data := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "alice", "roles": []string{"admin", "dev"}},
+			map[string]interface{}{"name": "bob"},
+		},
+		"items": []map[string]interface{}{{"name": "apple"}, {"name": "pear"}, {"name": "plum"}},
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`alice`, `%(users[0].name)s`, data),
+		ftc(`bob`, `%(users[1].name)s`, data),
+		ftc(`dev`, `%(users[0].roles[1])s`, data),
+		ftc(`plum`, `%(items[2].name)s`, data),
+	})
+
+	_, err := sprintfjs.Format(`%(users[2].name)s`, data)
+	if err == nil || !strings.Contains(err.Error(), "out of range") || !strings.Contains(err.Error(), "users[2].name") {
+		t.Fatalf("expected an out of range error naming the key path, had %v", err)
+	}
+}
+
+func TestFormatContextArg(t *testing.T) {
+	ctx := map[string]interface{}{"who": "world", "greeting": "Hello"}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`Hello world!`, `%(greeting)s %(who)s!`, ctx),
+		ftc(`Hello world!`, `%(greeting)s %(who)s!`, ctx, "ignored"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ContextArg: 1}, []formatterTestcase{
+		ftc(`Hello world!`, `%(greeting)s %(who)s!`, ctx, "ignored"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ContextArg: 2}, []formatterTestcase{
+		ftc(`Hello world!`, `%(greeting)s %(who)s!`, "ignored", ctx),
+	})
+
+	if _, err := (&sprintfjs.Formatter{ContextArg: 3}).Format(`%(who)s`, "ignored", ctx); err == nil {
+		t.Errorf("expected an out of range context argument to fail")
+	}
+
+}
+
+func TestFormatMixedPlaceholders(t *testing.T) {
+	alice := map[string]interface{}{"city": "Berlin", "age": 42}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`alice lives in Berlin`, `%s lives in %(city)s`, "alice", alice),
+		ftc(`Berlin (42): alice`, `%(city)s (%(age)d): %2$s`, alice, "alice"),
+		ftc(`alice lives in Berlin`, `%2$s lives in %(city)s`, alice, "alice"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{ContextArg: 2}, []formatterTestcase{
+		ftc(`alice lives in Berlin`, `%1$s lives in %(city)s`, "alice", alice),
+	})
+
+	for _, format := range []string{`%1$s lives in %(city)s`, `%(city)s: %s (%(age)d)`} {
+		if _, err := sprintfjs.Format(format, "alice", alice); err == nil {
+			t.Errorf("expected the named placeholder of %q to read a string argument", format)
+		}
+	}
+}
+
+type address struct {
+	City    string
+	ZipCode string `json:"zip"`
+}
+
+type person struct {
+	Who     string
+	Addr    *address `json:"address,omitempty"`
+	Hidden  string   `json:"-"`
+	private string
+}
+
+func TestFormatStructFields(t *testing.T) {
+	p := person{Who: "world", Addr: &address{City: "Berlin", ZipCode: "10115"}, Hidden: "h", private: "p"}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`Hello world`, `Hello %(Who)s`, struct{ Who string }{"world"}),
+		ftc(`world`, `%(who)s`, p),
+		ftc(`world`, `%(who)s`, &p),
+		ftc(`Berlin`, `%(Addr.City)s`, p),
+		ftc(`Berlin`, `%(address.city)s`, p),
+		ftc(`10115`, `%(Addr.zip)s`, p),
+		ftc(`10115`, `%(Addr.ZipCode)s`, p),
+		ftc(`Berlin`, `%(people[0].Addr.City)s`, map[string]interface{}{"people": []person{p}}),
+		ftc(`3`, `%(counts.b)d`, map[string]map[string]int{"counts": {"a": 1, "b": 3}}),
+	})
+
+	for _, format := range []string{`%(Hidden)s`, `%(private)s`, `%(Missing)s`, `%(Who.Name)s`} {
+		if _, err := sprintfjs.Format(format, p); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+}
+
+func TestFormatIndexAccessReflect(t *testing.T) {
+	names := []string{"alice", "bob"}
+	data := map[string]interface{}{
+		"names":  names,
+		"ints":   []int{1, 2, 3},
+		"array":  [2]float64{1.5, 2.5},
+		"ptr":    &names,
+		"people": []*person{{Who: "carol"}},
+		"bytes":  []byte("hi"),
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`bob`, `%(names[1])s`, data),
+		ftc(`3`, `%(ints[2])d`, data),
+		ftc(`+2`, `%(ints[1])+d`, data),
+		ftc(`2.5`, `%(array[1])f`, data),
+		ftc(`alice`, `%(ptr[0])s`, data),
+		ftc(`carol`, `%(people[0].Who)s`, data),
+		ftc(`104`, `%(bytes[0])d`, data),
+		ftc(`"bob"`, `%(names[1])j`, data),
+		ftc(`alice, bob`, `%(ptr[*])s`, data),
+	})
+
+	for _, format := range []string{`%(ints[3])d`, `%(array[2])f`, `%(ptr[2])s`} {
+		if _, err := sprintfjs.Format(format, data); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+}
+
+func TestFormatNestedAccess(t *testing.T) {
+	type config struct {
+		Labels map[string]string
+		Limits map[int]uint
+		Owner  *person
+	}
+	data := map[string]interface{}{
+		"a":      map[string]map[string]string{"b": {"c": "deep"}},
+		"config": &config{Labels: map[string]string{"env": "prod"}, Limits: map[int]uint{5: 100}, Owner: &person{Who: "dave"}},
+		"yaml":   map[interface{}]interface{}{"servers": map[interface{}]interface{}{"web": "10.0.0.1", 8080: "http"}},
+		"nil":    map[string]interface{}{"value": nil},
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`deep`, `%(a.b.c)s`, data),
+		ftc(`prod`, `%(config.Labels.env)s`, data),
+		ftc(`100`, `%(config.Limits[5])d`, data),
+		ftc(`dave`, `%(config.Owner.Who)s`, data),
+		ftc(`10.0.0.1 http`, `%(yaml.servers.web)s %(yaml.servers[8080])s`, data),
+		ftc(`<nil>`, `%(a.b.missing)v`, data),
+		ftc(`<nil>`, `%(config.Limits[6])v`, data),
+	})
+
+	testcases := []struct {
+		format string
+		error  string
+	}{
+		{`%(a.x.c)s`, `Path "a.x.c" not found at segment "x"`},
+		{`%(missing.b)s`, `Path "missing.b" not found at segment "missing"`},
+		{`%(config.Labels.team.name)s`, `not found at segment "team"`},
+		{`%(config.Limits[6].x)s`, `not found at segment "[6]"`},
+		{`%(nil.value.x)s`, `Cannot access property "x" of nil`},
+		{`%(config.Limits.x)s`, `Cannot access property "x" in value of type map[int]uint`},
+	}
+	for _, tc := range testcases {
+		_, err := sprintfjs.Format(tc.format, data)
+		if !errors.Is(err, sprintfjs.ErrInvalidKey) || !strings.Contains(err.Error(), tc.error) {
+			t.Errorf("expected %q to fail with %q, had %v", tc.format, tc.error, err)
+		}
+	}
+}
+
+func TestFormatDefault(t *testing.T) {
+	var nobody *person
+	data := map[string]interface{}{
+		"name":    "alice",
+		"empty":   "",
+		"nil":     nil,
+		"nobody":  nobody,
+		"user":    map[string]interface{}{"name": "bob", "tags": []string{"a"}},
+		"count":   3,
+		"created": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`Hello alice`, `Hello %(name:guest)s`, data),
+		ftc(`Hello guest`, `Hello %(missing:guest)s`, data),
+		ftc(`Hello guest`, `Hello %(nil:guest)s`, data),
+		ftc(`Hello guest`, `Hello %(nobody:guest)s`, data),
+		ftc(`Hello []`, `Hello [%(empty:guest)s]`, data),
+		ftc(`bob`, `%(user.name:guest)s`, data),
+		ftc(`guest`, `%(user.nickname:guest)s`, data),
+		ftc(`guest`, `%(account.name:guest)s`, data),
+		ftc(`guest`, `%(nil.name:guest)s`, data),
+		ftc(`none`, `%(user.tags[3]:none)s`, data),
+		ftc(`guest`, `%(name:guest)s`, nil),
+		ftc(`  guest`, `%(missing:guest)7s`, data),
+		ftc(`003 000`, `%(count:0)03d %(missing:0)03d`, data),
+		ftc(`"guest"`, `%(missing:guest)j`, data),
+		ftc(`2020-01-02 never`, `%(created:never|2006-01-02)s %(deleted:never|2006-01-02)s`, data),
+		ftc(`Hello !`, `Hello %(missing:)s!`, data),
+		ftc(`Hello alice`, `Hello %(name:)s? %(missing:)s?`, data),
+		ftc(`a: b`, `%(missing:a: b)s`, data),
+	})
+
+	for _, format := range []string{`%(missing)d`, `%(name.first:x)s`, `%(user.name.first:x)s`} {
+		if _, err := sprintfjs.Format(format, data); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+
+	ast := mustParse(t, `%(user.name:guest)s`)
+	if !ast[0].HasDefault || ast[0].Default != "guest" || ast.String() != `%(user.name:guest)s` {
+		t.Errorf("expected the default to be parsed, had %#v", ast[0])
+	}
+	if ast.Equal(mustParse(t, `%(user.name)s`)) || mustParse(t, `%(a:)s`).Equal(mustParse(t, `%(a)s`)) {
+		t.Errorf("expected defaults to tell placeholders apart")
+	}
+}
@@ -0,0 +1,149 @@
+package sprintfjs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is the severity of a lint issue.
+type Severity int
+
+const (
+	// SeverityInfo marks issues that are merely stylistic.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks issues that most likely are mistakes.
+	SeverityWarning
+	// SeverityError marks format strings that cannot be used at all.
+	SeverityError
+)
+
+// String implements `fmt.Stringer`
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// LintIssue is an advisory reported by `Lint`.
+type LintIssue struct {
+	Severity Severity
+	Message  string
+	Offset   int // byte offset in the format string
+}
+
+// String implements `fmt.Stringer`
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%d: %s: %s", i.Offset, i.Severity, i.Message)
+}
+
+// Lint checks a format string for common mistakes.
+// Unlike `Parse` errors the reported issues are advisories, the format string may still be usable.
+// A format string that fails to parse is reported as a single issue with `SeverityError` at the offset of the invalid placeholder.
+func Lint(format string) []LintIssue {
+	ast, err := Parse(format)
+	if err != nil {
+		issue := LintIssue{Severity: SeverityError, Message: err.Error()}
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			issue.Offset = parseErr.Offset
+		}
+		return []LintIssue{issue}
+	}
+	return ast.Lint()
+}
+
+// Lint checks an abstract syntax tree for common mistakes. See `Lint`.
+func (a AST) Lint() []LintIssue {
+	issues := []LintIssue{}
+	issue := func(severity Severity, offset int, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Severity: severity, Message: fmt.Sprintf(format, args...), Offset: offset})
+	}
+
+	implicit := 0
+	explicit := map[int]int{} // argument index => offset of first use
+	keys := map[string]string{}
+
+	for _, node := range a {
+		if node.Placeholder == "" {
+			continue
+		}
+
+		if node.DynamicWidth {
+			implicit++
+		}
+		if node.DynamicPrecision {
+			implicit++
+		}
+		if node.ParamNo != 0 {
+			if _, ok := explicit[node.ParamNo]; !ok {
+				explicit[node.ParamNo] = node.Offset
+			}
+		} else if node.Keys == nil {
+			implicit++
+		}
+
+		if (node.Precision != "" || node.DynamicPrecision) && !usesPrecision(node.Type) {
+			issue(SeverityWarning, node.Offset, "precision is ignored by %q", node.Placeholder)
+		}
+
+		if node.Case != "" && node.Type != "s" {
+			issue(SeverityWarning, node.Offset, "case flag is ignored by %q", node.Placeholder)
+		}
+
+		m := rePlaceholder.FindStringSubmatch(node.Placeholder)
+		if m == nil {
+			continue
+		}
+		// e.g. %-0s, or %0s, whose 0 is parsed as the pad flag of an omitted width
+		if (m[phWidth] != "" || m[phPad] == "0") && !node.DynamicWidth && node.Width == 0 {
+			issue(SeverityWarning, node.Offset, "width of zero has no effect in %q", node.Placeholder)
+		}
+		if node.Keys != nil {
+			path := keyPath(node.Keys)
+			folded := strings.ToLower(path)
+			if other, ok := keys[folded]; ok && other != path {
+				issue(SeverityWarning, node.Offset, "named argument %q differs from %q only in case", path, other)
+			} else if !ok {
+				keys[folded] = path
+			}
+		}
+	}
+
+	if implicit > 0 && len(explicit) > 0 {
+		issue(SeverityWarning, 0, "mixing explicit and implicit positional placeholders; implicit placeholders ignore explicit indices")
+	}
+
+	indices := make([]int, 0, len(explicit))
+	for paramNo := range explicit {
+		indices = append(indices, paramNo)
+	}
+	sort.Ints(indices)
+	for i, paramNo := 0, 1; i < len(indices); paramNo++ {
+		if paramNo == indices[i] {
+			i++
+			continue
+		}
+		if paramNo > implicit {
+			issue(SeverityWarning, explicit[indices[i]], "positional argument %d is never used", paramNo)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Offset < issues[j].Offset })
+	return issues
+}
+
+func usesPrecision(typ string) bool {
+	switch typ {
+	case "a", "e", "f", "g", "A", "E", "G", "P", "z", "q", "s", "t", "v", "C", "H":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestLint(t *testing.T) {
+	format := `%3$s %1$.2d %1$-0s %1$0s`
+	if _, err := sprintfjs.Parse(format); err != nil {
+		t.Fatalf("expected %q to parse, had %v", format, err)
+	}
+
+	issues := sprintfjs.Lint(format)
+
+	expected := []sprintfjs.LintIssue{
+		{Severity: sprintfjs.SeverityWarning, Message: `positional argument 2 is never used`, Offset: 0},
+		{Severity: sprintfjs.SeverityWarning, Message: `precision is ignored by "%1$.2d"`, Offset: 5},
+		{Severity: sprintfjs.SeverityWarning, Message: `width of zero has no effect in "%1$-0s"`, Offset: 12},
+		{Severity: sprintfjs.SeverityWarning, Message: `width of zero has no effect in "%1$0s"`, Offset: 19},
+	}
+	if len(expected) != len(issues) {
+		t.Fatalf("expected %d issues had %v", len(expected), issues)
+	}
+	for i := range expected {
+		if expected[i] != issues[i] {
+			t.Errorf("expected %v had %v", expected[i], issues[i])
+		}
+	}
+}
+
+func TestLintNamed(t *testing.T) {
+	issues := sprintfjs.Lint(`%(user.Name)s %(user.name)s %(items[1])s`)
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue had %v", issues)
+	}
+	if issues[0].Offset != 14 {
+		t.Fatalf("unexpected offset in %v", issues)
+	}
+}
+
+func TestLintMixed(t *testing.T) {
+	issues := sprintfjs.Lint(`%s %2$s`)
+	if len(issues) != 1 || issues[0].Severity != sprintfjs.SeverityWarning {
+		t.Fatalf("expected a single warning had %v", issues)
+	}
+}
+
+func TestLintParseError(t *testing.T) {
+	issues := sprintfjs.Lint(`total: %y`)
+	if len(issues) != 1 || issues[0].Severity != sprintfjs.SeverityError || issues[0].Offset != 7 {
+		t.Fatalf("expected a single error at offset 7 had %v", issues)
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	if issues := sprintfjs.Lint(`%2$s %1$5.1f %1$05d %%`); len(issues) != 0 {
+		t.Fatalf("expected no issues had %v", issues)
+	}
+}
+
+func TestLintCase(t *testing.T) {
+	issues := sprintfjs.Lint(`%^s %^d`)
+	if len(issues) != 1 || issues[0].Offset != 4 || issues[0].Message != `case flag is ignored by "%^d"` {
+		t.Fatalf("expected a single warning for %%^d had %v", issues)
+	}
+}
@@ -0,0 +1,134 @@
+package sprintfjs
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// numberSymbols are the separators used to format decimal numbers.
+type numberSymbols struct {
+	Decimal   string
+	Group     string
+	GroupSize int
+}
+
+// locales maps language tags to their number separators.
+// Tags are matched case-insensitively, falling back to the language, e.g. "de-AT" to "de".
+var locales = map[string]numberSymbols{
+	"en":    {".", ",", 3},
+	"de":    {",", ".", 3},
+	"de-ch": {".", "’", 3},
+	"es":    {",", ".", 3},
+	"fr":    {",", " ", 3},
+	"fr-ch": {".", " ", 3},
+	"it":    {",", ".", 3},
+	"ja":    {".", ",", 3},
+	"nl":    {",", ".", 3},
+	"pl":    {",", " ", 3},
+	"pt":    {",", ".", 3},
+	"ru":    {",", " ", 3},
+	"sv":    {",", " ", 3},
+	"zh":    {".", ",", 3},
+}
+
+// numberSymbols returns the separators of the locale with the overrides applied.
+func (f *Formatter) numberSymbols() (numberSymbols, error) {
+	symbols := locales["en"]
+	if f.Locale != "" {
+		tag := strings.ToLower(strings.Replace(f.Locale, "_", "-", -1))
+		var ok bool
+		if symbols, ok = locales[tag]; !ok {
+			if i := strings.Index(tag, "-"); i > 0 {
+				symbols, ok = locales[tag[:i]]
+			}
+			if !ok {
+				return symbols, fmt.Errorf("[sprintf] unknown locale %q", f.Locale)
+			}
+		}
+	}
+
+	if f.DecimalSeparator != "" {
+		symbols.Decimal = f.DecimalSeparator
+	}
+	if f.GroupSeparator != "" {
+		symbols.Group = f.GroupSeparator
+	}
+	if f.GroupSize > 0 {
+		symbols.GroupSize = f.GroupSize
+	}
+	return symbols, nil
+}
+
+// localizeNumber applies the decimal separator and, if `group` is set, the grouping separator to an unsigned decimal number.
+// Grouped numbers are padded with leading zeros to at least `zeroPad` characters, e.g. 0,001,234 instead of 0001,234.
+func (f *Formatter) localizeNumber(number string, group bool, zeroPad int) (string, error) {
+	symbols, err := f.numberSymbols()
+	if err != nil {
+		return "", err
+	}
+
+	digits := 0
+	for digits < len(number) && number[digits] >= '0' && number[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return number, nil // NaN, Inf
+	}
+
+	integer := number[:digits]
+	rest := number[digits:]
+	if strings.HasPrefix(rest, ".") {
+		rest = symbols.Decimal + rest[1:]
+	}
+	if !group || symbols.GroupSize <= 0 {
+		return integer + rest, nil
+	}
+
+	groups := func(n int) int { return (n - 1) / symbols.GroupSize }
+	width := func(n int) int {
+		return n + groups(n)*utf8.RuneCountInString(symbols.Group) + utf8.RuneCountInString(rest)
+	}
+	if n := len(integer); width(n) < zeroPad {
+		for width(n) < zeroPad {
+			n++
+		}
+		integer = strings.Repeat("0", n-len(integer)) + integer
+	}
+
+	localized := strings.Builder{}
+	for i := range integer {
+		if i > 0 && (len(integer)-i)%symbols.GroupSize == 0 {
+			localized.WriteString(symbols.Group)
+		}
+		localized.WriteByte(integer[i])
+	}
+	localized.WriteString(rest)
+	return localized.String(), nil
+}
+
+func isDecimalType(typ string) bool {
+	switch typ {
+	case "d", "i", "n", "u", "e", "f", "g", "E", "G", "P", "z":
+		return true
+	}
+	return false
+}
+
+// convertCase converts a string to upper case ("^"), lower case ("_") or title case ("~") following the rules of the locale,
+// e.g. "i" becomes "İ" in Turkish.
+func (f *Formatter) convertCase(s string, flag string) string {
+	tag := language.Make(strings.Replace(f.Locale, "_", "-", -1))
+	switch flag {
+	case "^":
+		return cases.Upper(tag).String(s)
+	case "_":
+		return cases.Lower(tag).String(s)
+	case "~":
+		return cases.Title(tag).String(s)
+	}
+	return s
+}
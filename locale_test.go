@@ -0,0 +1,62 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatLocale(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`1,234,567.89`, `%,.2f`, 1234567.89),
+		ftc(`1234567.89`, `%.2f`, 1234567.89),
+		ftc(`-1,234,567`, `%,d`, -1234567),
+		ftc(`123`, `%,d`, 123),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "en"}, []formatterTestcase{
+		ftc(`1,234,567.89`, `%,.2f`, 1234567.89),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de"}, []formatterTestcase{
+		ftc(`1.234.567,89`, `%,.2f`, 1234567.89),
+		ftc(`1234567,89`, `%.2f`, 1234567.89),
+		ftc(`+1.234.567`, `%+,d`, 1234567),
+		ftc(`1,5e+03`, `%e`, 1500),
+		ftc(`1234.5`, `%s`, "1234.5"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de_AT"}, []formatterTestcase{
+		ftc(`1.234.567,89`, `%,.2f`, 1234567.89),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "fr"}, []formatterTestcase{
+		ftc("1 234 567,89", `%,.2f`, 1234567.89),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de", GroupSeparator: "'", DecimalSeparator: ".", GroupSize: 4}, []formatterTestcase{
+		ftc(`123'4567.89`, `%,.2f`, 1234567.89),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`  1,234,567|`, `%,11d|`, 1234567),
+		ftc(`1,234     |`, `%,-10d|`, 1234),
+		ftc(`+1,234`, `%+,d`, 1234),
+		ftc(`1,234,567`, `%,u`, uint64(1234567)),
+		ftc(`1,234,567.5`, `%,.1f`, 1234567.5),
+		ftc(`1,234,567.5`, `%,.8g`, 1234567.5),
+		ftc(`-0,001,234`, `%,010d`, -1234),
+		ftc(`01,234`, `%,06d`, 1234),
+		ftc(`0,001,234.50`, `%,012.2f`, 1234.5),
+		ftc(`***-1,234.50`, `%,'*12.2f`, -1234.5),
+		ftc(`1,234`, `%,d`, "1234"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de"}, []formatterTestcase{
+		ftc(`-0.001.234,50`, `%,013.2f`, -1234.5),
+	})
+
+	if _, err := (&sprintfjs.Formatter{Locale: "xx"}).Format(`%d`, 1); err == nil {
+		t.Fatal("expected an unknown locale to fail")
+	}
+}
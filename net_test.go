@@ -0,0 +1,35 @@
+package sprintfjs_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatNet(t *testing.T) {
+	ipv4 := net.ParseIP("192.0.2.1")
+	ipv6 := net.ParseIP("2001:db8::1")
+	_, cidr, err := net.ParseCIDR("192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`192.0.2.1`, `%s`, ipv4),
+		ftc(`2001:db8::1`, `%s`, ipv6),
+		ftc(`192.0.2.0/24`, `%s`, cidr),
+		ftc(`192.0.2.0/24`, `%s`, *cidr),
+		ftc(`   192.0.2.1`, `%12s`, ipv4),
+
+		ftc(`"192.0.2.1"`, `%j`, ipv4),
+		ftc(`"2001:db8::1"`, `%j`, ipv6),
+		ftc(`"192.0.2.0/24"`, `%j`, cidr),
+		ftc(`"192.0.2.0/24"`, `%j`, *cidr),
+
+		ftc(`ipaddr`, `%T`, ipv4),
+		ftc(`ipaddr`, `%T`, ipv6),
+		ftc(`ipnet`, `%T`, cidr),
+		ftc(`ipnet`, `%T`, *cidr),
+	})
+}
@@ -1,19 +1,62 @@
 package sprintfjs
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
+	"strings"
 )
 
 // Number represents a number.
-// Similar in Javascript strings are also considered numbers.
+// Similar in Javascript strings are also considered numbers, and so are `json.Number`s.
+// Other number types, e.g. int16 or named types, are converted to a number type of the same kind,
+// e.g. a `time.Duration` is its number of nanoseconds. Like in JavaScript booleans are 1 and 0.
+// *big.Int, *big.Float and decimal types with `String() string` and `Float64() (float64, bool)` methods,
+// e.g. github.com/shopspring/decimal.Decimal, are formatted from their exact string form.
 type Number struct {
 	value interface{}
 }
 
 // NewNumber creates a new number.
 func NewNumber(value interface{}) Number {
-	return Number{value}
+	return Number{basicNumber(value)}
+}
+
+// basicNumbers are the number types by reflect kind that `basicNumber` converts other number types to.
+var basicNumbers = map[reflect.Kind]reflect.Type{
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int32(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint32(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Uintptr: reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
+
+// basicNumber converts int16, uint16, uintptr and named number types, e.g. `time.Duration`,
+// to the number types handled by `Number`, and booleans to 1 and 0. Other values are returned as they are.
+func basicNumber(value interface{}) interface{} {
+	vv := reflect.ValueOf(value)
+	if vv.Kind() == reflect.Bool {
+		if vv.Bool() {
+			return 1
+		}
+		return 0
+	}
+	typ, ok := basicNumbers[vv.Kind()]
+	if !ok || vv.Type() == typ {
+		return value
+	}
+	return vv.Convert(typ).Interface()
 }
 
 // Format implements `fmt.Formatter`
@@ -23,14 +66,18 @@ func (n Number) Format(f fmt.State, c rune) {
 		fmt.Fprintf(f, "%b", n.value)
 
 	case 'u':
-		i64, err := n.Unsigned().Int64()
+		u64, err := n.Unsigned().asUint64()
 		if err != nil {
 			fmt.Fprintf(f, "%%!u(%T=%v)", n.value, n.value)
 			return
 		}
-		fmt.Fprintf(f, "%d", i64)
+		fmt.Fprintf(f, "%d", u64)
 
 	case 'i', 'd':
+		if u64, ok := n.largeUnsigned(); ok {
+			fmt.Fprintf(f, "%d", u64) // e.g. math.MaxUint64, which overflows int64
+			return
+		}
 		i64, err := n.Int64()
 		if err != nil {
 			fmt.Fprintf(f, "%%!%c(%T=%v)", c, n.value, n.value)
@@ -38,7 +85,11 @@ func (n Number) Format(f fmt.State, c rune) {
 		}
 		fmt.Fprintf(f, "%d", i64)
 
-	case 'e', 'f', 'g':
+	case 'e', 'f', 'g', 'E', 'G':
+		if s, ok := decimalString(n.value); ok && c == 'f' {
+			fmt.Fprint(f, formatDecimal(s, f))
+			return
+		}
 		f64, err := n.Float64()
 		if err != nil {
 			fmt.Fprintf(f, "%%!%c(%T=%v)", c, n.value, n.value)
@@ -49,22 +100,44 @@ func (n Number) Format(f fmt.State, c rune) {
 			prec = -1
 		}
 		s := strconv.FormatFloat(f64, byte(c), prec, 64)
-		if c == 'e' {
+		if c == 'e' || c == 'E' {
 			s = trimExcessZerosFromExponent(s) // "2e+00" => "2e+0"
 		}
 		fmt.Fprint(f, s)
 
+	case 'P':
+		if _, err := n.Float64(); err != nil {
+			fmt.Fprintf(f, "%%!%c(%T=%v)", c, n.value, n.value)
+			return
+		}
+		n.Percent().Format(f, 'f')
+		fmt.Fprint(f, "%")
+
+	case 'a', 'A':
+		f64, err := n.Float64()
+		if err != nil {
+			fmt.Fprintf(f, "%%!%c(%T=%v)", c, n.value, n.value)
+			return
+		}
+		prec, ok := f.Precision()
+		if !ok {
+			prec = -1
+		}
+		fmt.Fprint(f, trimBinaryExponent(strconv.FormatFloat(f64, byte(c)+'x'-'a', prec, 64)))
+
 	case 'x', 'X', 'o':
-		i64, err := n.Unsigned().Int64()
+		u64, err := n.Unsigned().asUint64()
 		if err != nil {
 			fmt.Fprintf(f, "%%!%c(%T=%v)", c, n.value, n.value)
 			return
 		}
-		fmt.Fprintf(f, fmt.Sprintf("%%%c", c), i64)
+		fmt.Fprintf(f, fmt.Sprintf("%%%c", c), u64)
 	}
 }
 
-// IsPositive returns true if the number is considered positive.
+// IsPositive returns true if the number is considered positive, i.e. not negative.
+// Numbers of types without a sign of their own, e.g. types with a `Float64` method, are positive if their float64 value is.
+// Values that are not numbers are not positive.
 func (n Number) IsPositive() bool {
 	switch v := n.value.(type) {
 	case int:
@@ -81,13 +154,19 @@ func (n Number) IsPositive() bool {
 		return v >= 0
 	case uint, uint8, uint32, uint64:
 		return true
-	case string:
+	case string, json.Number:
 		f64, err := n.Float64()
 		if err != nil {
 			return false
 		}
 		return f64 >= 0
 	}
+	if s, ok := decimalString(n.value); ok {
+		return !strings.HasPrefix(s, "-")
+	}
+	if f64, err := n.Float64(); err == nil {
+		return f64 >= 0
+	}
 	return false
 }
 
@@ -116,6 +195,14 @@ func (n Number) Float64() (float64, error) {
 		return v, nil
 	case string:
 		return strconv.ParseFloat(v, 64)
+	case json.Number:
+		return v.Float64()
+	}
+	if s, ok := decimalString(n.value); ok {
+		return strconv.ParseFloat(s, 64)
+	}
+	if f64, ok := float64Value(n.value); ok {
+		return f64, nil
 	}
 	return 0.0, fmt.Errorf("Cannot use %T as float64", n.value)
 }
@@ -145,32 +232,201 @@ func (n Number) Int64() (int64, error) {
 		return int64(v), nil
 	case string:
 		return strconv.ParseInt(v, 10, 64)
+	case json.Number:
+		if i64, err := v.Int64(); err == nil {
+			return i64, nil
+		}
+		f64, err := v.Float64()
+		return int64(f64), err
+	}
+	if s, ok := decimalString(n.value); ok {
+		if i64, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i64, nil
+		}
+		f64, err := strconv.ParseFloat(s, 64)
+		return int64(f64), err
+	}
+	if f64, ok := float64Value(n.value); ok {
+		return int64(f64), nil
 	}
 	return 0.0, fmt.Errorf("Cannot use %T as int64", n.value)
 }
 
+// asUint64 returns the number as uint64, performs conversion if necessary.
+// Negative integers are converted to their two's complement, e.g. -1 to 18446744073709551615.
+func (n Number) asUint64() (uint64, error) {
+	switch v := n.value.(type) {
+	case uint:
+		return uint64(v), nil
+	case uint8:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case string:
+		if u64, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return u64, nil
+		}
+	case json.Number:
+		if u64, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u64, nil
+		}
+	}
+	if s, ok := decimalString(n.value); ok {
+		if u64, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u64, nil
+		}
+	}
+	i64, err := n.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("Cannot use %T as uint64", n.value)
+	}
+	return uint64(i64), nil
+}
+
+// largeUnsigned returns unsigned integers beyond the range of int64, e.g. math.MaxUint64.
+func (n Number) largeUnsigned() (uint64, bool) {
+	var u64 uint64
+	switch v := n.value.(type) {
+	case uint:
+		u64 = uint64(v)
+	case uint64:
+		u64 = v
+	case string:
+		u64, _ = strconv.ParseUint(v, 10, 64)
+	default:
+		if s, ok := decimalString(n.value); ok {
+			u64, _ = strconv.ParseUint(s, 10, 64)
+		}
+	}
+	return u64, u64 > math.MaxInt64
+}
+
 // IsNaN returns true if the number is not a number.
 func (n Number) IsNaN() bool {
 	switch n.value.(type) {
 	case int, int8, int32, int64, uint, uint8, uint32, uint64, float32, float64:
 		return false
-	case string:
+	case string, json.Number:
 		if _, err := n.Float64(); err == nil {
 			return false
 		}
+	default:
+		if _, ok := decimalString(n.value); ok {
+			return false
+		}
+		if _, ok := float64Value(n.value); ok {
+			return false
+		}
 	}
 	return true
 }
 
+// decimalNumber is implemented by decimal types, e.g. github.com/shopspring/decimal.Decimal, whose String method yields
+// the exact number. Other `fmt.Stringer`s are not numbers, even if their string form looks like one.
+type decimalNumber interface {
+	String() string
+	Float64() (float64, bool)
+}
+
+// decimalString returns the string form of json.Number, *big.Int, *big.Float and decimal types. A panicking String method or a string form
+// that is not a number is not a decimal.
+func decimalString(v interface{}) (s string, ok bool) {
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case *big.Int:
+		if v == nil {
+			return "", false
+		}
+		s = v.String()
+	case *big.Float:
+		if v == nil {
+			return "", false
+		}
+		s = v.Text('g', -1)
+	}
+	if s != "" {
+		_, err := strconv.ParseFloat(s, 64)
+		return s, err == nil
+	}
+
+	d, ok := v.(decimalNumber)
+	if !ok {
+		return "", false
+	}
+	defer func() {
+		if recover() != nil {
+			s, ok = "", false
+		}
+	}()
+	s = d.String()
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// float64Value returns the value of types with a `Float64() (float64, bool)` method.
+func float64Value(v interface{}) (float64, bool) {
+	if f, ok := v.(interface{ Float64() (float64, bool) }); ok {
+		f64, _ := f.Float64()
+		return f64, true
+	}
+	return 0, false
+}
+
+// formatDecimal formats a decimal string with the precision of `f` without converting to binary floating point.
+// Halves are rounded away from zero.
+func formatDecimal(s string, f fmt.State) string {
+	prec, ok := f.Precision()
+	if !ok {
+		return s
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return s
+	}
+	return r.FloatString(prec)
+}
+
+// Percent returns the number multiplied by 100, e.g. 12.34 for 0.1234.
+// It shifts the decimal point of the shortest decimal representation instead of multiplying binary floating point numbers,
+// which would yield 12.340000000000002.
+func (n Number) Percent() Number {
+	s, ok := decimalString(n.value)
+	if !ok {
+		f64, err := n.Float64()
+		if err != nil {
+			return n
+		}
+		s = strconv.FormatFloat(f64, 'e', -1, 64)
+	}
+	neg, digits, exp, ok := splitDecimal(s)
+	if !ok {
+		f64, _ := n.Float64()
+		return NewNumber(f64 * 100) // NaN or infinity
+	}
+	return NewNumber(roundedDecimal(joinDecimal(neg, digits, exp+2)))
+}
+
 // Unsigned returns an unsigned version of the number.
 func (n Number) Unsigned() Number {
 	return NewNumber(unsigned(n.value))
 }
 
+// unsigned converts negative integers to their two's complement.
+// Like JavaScript's `>>> 0` it uses 32 bits for int8 and int32, which `NewNumber` converts int16 to,
+// and for int values that fit into 32 bits, e.g. -2 becomes 4294967294. Other int values and int64 use 64 bits,
+// so no bits are lost.
 func unsigned(v interface{}) interface{} {
 	switch v := v.(type) {
 	case int:
-		return uint32(v)
+		if v >= math.MinInt32 && v <= math.MaxInt32 {
+			return uint32(v)
+		}
+		return uint64(v)
 	case int8:
 		return uint32(v)
 	case int32:
@@ -181,9 +437,39 @@ func unsigned(v interface{}) interface{} {
 	return v
 }
 
+// ExponentStyle is the number of digits of exponents in scientific notation.
+type ExponentStyle int
+
+const (
+	// ExponentDefault keeps a single digit for a zero exponent and at least two digits otherwise,
+	// e.g. 2e+0 and 1.5e+03. %g always uses at least two digits, like Go.
+	ExponentDefault ExponentStyle = iota
+	// ExponentSingleDigit drops all leading zeros of exponents like JavaScript's toExponential, e.g. 2e+0 and 1.5e+3.
+	ExponentSingleDigit
+	// ExponentTwoDigits uses at least two exponent digits like Go and C, e.g. 2e+00 and 1.5e+03.
+	ExponentTwoDigits
+)
+
+// apply rewrites the exponent of a number formatted in scientific notation, e.g. 1.5e+03.
+// Numbers without exponent are returned as is.
+func (style ExponentStyle) apply(number string) string {
+	i := strings.LastIndexAny(number, "eE")
+	if style == ExponentDefault || i < 0 || i+2 >= len(number) {
+		return number
+	}
+	digits := strings.TrimLeft(number[i+2:], "0")
+	if digits == "" {
+		digits = "0"
+	}
+	if style == ExponentTwoDigits && len(digits) < 2 {
+		digits = "0" + digits
+	}
+	return number[:i+2] + digits
+}
+
 // trimExcessZerosFromExponent removes duplicate zeros for a zero exponent: 2e+00 => 2e+0
 func trimExcessZerosFromExponent(s string) string {
-	l := len(s) -1
+	l := len(s) - 1
 	for l > 0 {
 		c := s[l]
 		if c == '+' {
@@ -195,4 +481,165 @@ func trimExcessZerosFromExponent(s string) string {
 		l--
 	}
 	return s
-}
\ No newline at end of file
+}
+
+// trimBinaryExponent removes leading zeros of the binary exponent of a hexadecimal float like C: 0x1p+01 => 0x1p+1
+func trimBinaryExponent(s string) string {
+	i := strings.LastIndexAny(s, "pP")
+	if i < 0 || i+2 >= len(s) {
+		return s
+	}
+	digits := strings.TrimLeft(s[i+2:], "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return s[:i+2] + digits
+}
+
+// formatOrdinal formats an integer as an English ordinal number, e.g. 1st, 2nd, 3rd, 11th or 22nd.
+func formatOrdinal(n Number) (string, error) {
+	i64, err := n.Int64()
+	if err != nil {
+		return "", err
+	}
+	if f64, err := n.Float64(); err != nil || f64 != float64(i64) {
+		return "", fmt.Errorf("%v is not an integer", n.value)
+	}
+
+	suffix := "th"
+	if m := i64 % 100; m < -13 || m > 13 || (m > -11 && m < 11) {
+		switch m % 10 {
+		case 1, -1:
+			suffix = "st"
+		case 2, -2:
+			suffix = "nd"
+		case 3, -3:
+			suffix = "rd"
+		}
+	}
+	return strconv.FormatInt(i64, 10) + suffix, nil
+}
+
+// byteUnits are the units of byte sizes by 1000 and by 1024.
+var byteUnits = map[bool][]string{
+	false: {"B", "kB", "MB", "GB", "TB", "PB", "EB"},
+	true:  {"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"},
+}
+
+// formatByteSize formats a number of bytes in the largest unit it reaches, e.g. 1.5 kB, or 1.5 KiB if `binary` is set.
+// The precision is the number of decimal places and defaults to 1. Sizes below one kilobyte are whole bytes, e.g. 512 B.
+func (f *Formatter) formatByteSize(n Number, precision string, binary bool) (string, error) {
+	f64, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	if f64 < 0 || math.IsNaN(f64) || math.IsInf(f64, 0) {
+		return "", fmt.Errorf("%v is not a byte size", n.value)
+	}
+	prec := 1
+	if precision != "" {
+		if prec, err = strconv.Atoi(precision); err != nil {
+			return "", wrapf(ErrInvalidPlaceholder, err, "[sprintf] failed to parse precision %q: %v", precision, err)
+		}
+	}
+
+	base := 1000.0
+	if binary {
+		base = 1024
+	}
+	units := byteUnits[binary]
+	unit := 0
+	for f64 >= base && unit < len(units)-1 {
+		f64 /= base
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatFloat(math.Floor(f64), 'f', 0, 64) + " B", nil
+	}
+
+	formatted, _ := formatWithPrecision("f", strconv.Itoa(prec), f.Rounding.round(NewNumber(f64), 'f', prec))
+	if rounded, _ := strconv.ParseFloat(formatted, 64); rounded >= base && unit < len(units)-1 {
+		// e.g. 999.96 kB rounds to 1.0 MB rather than 1000.0 kB
+		unit++
+		formatted, _ = formatWithPrecision("f", strconv.Itoa(prec), f.Rounding.round(NewNumber(f64/base), 'f', prec))
+	}
+	return formatted + " " + units[unit], nil
+}
+
+// formatEngineering formats a number in engineering notation: the exponent is a multiple of three.
+// The precision is the number of digits after the decimal point of the mantissa.
+func formatEngineering(n Number, precision string) (string, error) {
+	f64, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	prec := -1
+	if precision != "" {
+		if prec, err = strconv.Atoi(precision); err != nil {
+			return "", wrapf(ErrInvalidPlaceholder, err, "[sprintf] failed to parse precision %q: %v", precision, err)
+		}
+	}
+	if math.IsInf(f64, 0) || math.IsNaN(f64) {
+		return strconv.FormatFloat(f64, 'f', prec, 64), nil
+	}
+
+	// shift the decimal point of the shortest decimal representation to avoid binary rounding errors
+	sci := strconv.FormatFloat(math.Abs(f64), 'e', -1, 64) // e.g. "4.7e-06"
+	e := strings.IndexByte(sci, 'e')
+	exp, _ := strconv.Atoi(sci[e+1:])
+	digits := strings.Replace(sci[:e], ".", "", 1)
+
+	engExp := exp - ((exp%3)+3)%3
+	if f64 == 0 {
+		engExp = 0
+	}
+	mantissa, err := shiftedFloat(digits, exp-engExp)
+	if err != nil {
+		return "", err
+	}
+
+	formatted := strconv.FormatFloat(mantissa, 'f', prec, 64)
+	if rounded, _ := strconv.ParseFloat(formatted, 64); rounded >= 1000 { // e.g. 999.96 with precision 1
+		engExp += 3
+		formatted = strconv.FormatFloat(rounded/1000, 'f', prec, 64)
+	}
+
+	if f64 < 0 {
+		formatted = "-" + formatted
+	}
+	expSign := "+"
+	if engExp < 0 {
+		expSign = "-"
+		engExp = -engExp
+	}
+	return formatted + "e" + expSign + strconv.Itoa(engExp), nil
+}
+
+// shiftedFloat parses "d.ddd" given as "dddd" with the decimal point moved `shift` digits to the right.
+func shiftedFloat(digits string, shift int) (float64, error) {
+	for len(digits) < shift+1 {
+		digits += "0"
+	}
+	return strconv.ParseFloat(digits[:shift+1]+"."+digits[shift+1:], 64)
+}
+
+// complexValue returns the value of complex numbers.
+func complexValue(v interface{}) (complex128, bool) {
+	switch v := v.(type) {
+	case complex64:
+		return complex128(v), true
+	case complex128:
+		return v, true
+	}
+	return 0, false
+}
+
+// formatComplex formats a complex number without the parentheses of Go, e.g. "3+4i".
+// The precision is the number of digits after the decimal point of both the real and the imaginary part.
+func formatComplex(c complex128, precision string) string {
+	s := fmt.Sprint(c)
+	if precision != "" {
+		s = fmt.Sprintf("%."+precision+"f", c)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+}
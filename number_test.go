@@ -0,0 +1,459 @@
+package sprintfjs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+// decimal is a stub of a decimal type like github.com/shopspring/decimal.Decimal.
+type decimal struct {
+	s string
+}
+
+func (d decimal) String() string { return d.s }
+
+func (d decimal) Float64() (float64, bool) { return 0, false } // must not be used
+
+// measure has a floating point value but no string form.
+type measure struct {
+	value float64
+}
+
+func (m measure) Float64() (float64, bool) { return m.value, true }
+
+type name string
+
+func (n name) String() string { return string(n) }
+
+func TestFormatDecimal(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`12.50`, `%f`, decimal{"12.50"}),
+		ftc(`1.01`, `%.2f`, decimal{"1.005"}), // float64(1.005) would round to 1.00
+		ftc(`-1.01`, `%.2f`, decimal{"-1.005"}),
+		ftc(`2.68`, `%.2f`, decimal{"2.675"}),
+		ftc(`1234567.89`, `%.2f`, decimal{"1234567.891"}),
+		ftc(`+12.50`, `%+.2f`, decimal{"12.5"}),
+		ftc(`1,234.50`, `%,.2f`, decimal{"1234.5"}),
+		ftc(`12`, `%d`, decimal{"12"}),
+		ftc(`12`, `%d`, decimal{"12.5"}),
+		ftc(`-12`, `%d`, decimal{"-12"}),
+		ftc(`1.25e+01`, `%e`, decimal{"12.5"}),
+		ftc(`12.5`, `%g`, decimal{"12.5"}),
+		ftc(`number`, `%T`, decimal{"12.5"}),
+		ftc(`2.5`, `%f`, measure{2.5}),
+		ftc(`-2.50`, `%.2f`, measure{-2.5}),
+		ftc(`2`, `%d`, measure{2.5}),
+	})
+
+	if _, err := sprintfjs.Format(`%f`, name("twelve")); err == nil {
+		t.Errorf("expected a non-numeric Stringer to fail")
+	}
+}
+
+type panickingDecimal struct{}
+
+func (panickingDecimal) String() string { panic("boom") }
+
+func (panickingDecimal) Float64() (float64, bool) { return 1.5, false }
+
+func TestFormatStringerIsNoNumber(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`object`, `%T`, name("1.5")),
+		ftc(`object`, `%T`, panickingStringer{}),
+		ftc(`number`, `%T`, panickingDecimal{}),
+		ftc(`1`, `%d`, panickingDecimal{}),
+	})
+
+	for _, value := range []interface{}{name("1.5"), panickingStringer{}} {
+		for _, format := range []string{`%d`, `%x`, `%f`} {
+			if _, err := sprintfjs.Format(format, value); !errors.Is(err, sprintfjs.ErrTypeMismatch) {
+				t.Errorf("expected %s of %T to fail, had %v", format, value, err)
+			}
+		}
+	}
+}
+
+func TestFormatUint64(t *testing.T) {
+	const max = uint64(18446744073709551615)
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`18446744073709551615`, `%u`, max),
+		ftc(`ffffffffffffffff`, `%x`, max),
+		ftc(`FFFFFFFFFFFFFFFF`, `%X`, max),
+		ftc(`1777777777777777777777`, `%o`, max),
+		ftc(`9223372036854775808`, `%u`, uint64(1)<<63),
+		ftc(`18446744073709551615`, `%u`, "18446744073709551615"),
+		ftc(`18446744073709551615`, `%u`, int64(-1)),
+		ftc(`4294967294`, `%u`, -2),
+		ftc(`18446744073709551615`, `%d`, max),
+		ftc(`+18446744073709551615`, `%+d`, max),
+		ftc(`9223372036854775808`, `%i`, uint64(1)<<63),
+		ftc(`9223372036854775807`, `%d`, uint64(math.MaxInt64)),
+		ftc(`18,446,744,073,709,551,615`, `%,d`, max),
+		ftc(`18446744073709551615`, `%d`, json.Number("18446744073709551615")),
+		ftc(`18446744073709551615`, `%d`, "18446744073709551615"),
+	})
+
+	if _, err := sprintfjs.Format(`%u`, "x"); err == nil {
+		t.Fatal("expected a non-numeric string to fail")
+	}
+}
+
+func TestFormatUnsignedWidth(t *testing.T) {
+	if strconv.IntSize < 64 {
+		t.Skip("requires 64-bit int")
+	}
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`4294967294`, `%u`, -2),
+		ftc(`fffffffe`, `%x`, -2),
+		ftc(`2147483648`, `%u`, math.MinInt32),
+		ftc(`18446744071562067967`, `%u`, math.MinInt32-1),
+		ftc(`8589934592`, `%u`, 1<<33),
+		ftc(`200000000`, `%x`, 1<<33),
+		ftc(`18446744065119617024`, `%u`, -(1 << 33)),
+		ftc(`fffffffe00000000`, `%x`, -(1 << 33)),
+		ftc(`4294967294`, `%u`, int32(-2)),
+		ftc(`4294967294`, `%u`, int8(-2)),
+		ftc(`18446744073709551614`, `%u`, int64(-2)),
+	})
+}
+
+func TestFormatJSONNumber(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"id": 9007199254740993, "price": 1.005, "neg": -12}`))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`9007199254740993`, `%(id)d`, data),
+		ftc(`number`, `%(id)T`, data),
+		ftc(`1.01`, `%(price).2f`, data),
+		ftc(`1.005e+0`, `%(price)e`, data),
+		ftc(`-12`, `%(neg)d`, data),
+		ftc(`+9007199254740993`, `%(id)+d`, data),
+		ftc(`{"id":9007199254740993}`, `%j`, map[string]json.Number{"id": "9007199254740993"}),
+		ftc(`18446744073709551615`, `%u`, json.Number("18446744073709551615")),
+		ftc(`1`, `%d`, json.Number("1.5")),
+		ftc(`number`, `%T`, json.Number("invalid")),
+	})
+
+	if !sprintfjs.NewNumber(json.Number("invalid")).IsNaN() {
+		t.Error("expected an invalid json.Number to be NaN")
+	}
+	if _, err := sprintfjs.Format(`%d`, json.Number("invalid")); err == nil {
+		t.Error("expected an invalid json.Number to fail")
+	}
+}
+
+func TestFormatComplex(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`3+4i`, `%v`, complex(3, 4)),
+		ftc(`1.5-2i`, `%v`, complex64(complex(1.5, -2))),
+		ftc(`3.00+4.00i`, `%.2v`, complex(3, 4)),
+		ftc(`   3+4i`, `%7v`, complex(3, 4)),
+		ftc(`complex`, `%T`, complex(3, 4)),
+		ftc(`complex`, `%T`, complex64(1)),
+		ftc(`(3+4i)`, `%#v`, complex(3, 4)),
+	})
+
+	if _, err := sprintfjs.Format(`%d`, complex(3, 4)); err == nil {
+		t.Error("expected a complex number to fail as integer")
+	}
+}
+
+func TestFormatUpperCaseExponent(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{EngineeringNotation: true}, []formatterTestcase{
+		ftc(`12.3E+3`, `%.1E`, 12345),
+		ftc(`12.3e+3`, `%.1e`, 12345),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de"}, []formatterTestcase{
+		ftc(`1,5E+03`, `%E`, 1500),
+		ftc(`1,5`, `%G`, 1.5),
+	})
+}
+
+func TestFormatExponentDigits(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`2e+0`, `%e`, 2),
+		ftc(`1.5e+03`, `%e`, 1500),
+		ftc(`1e+100`, `%e`, 1e100),
+		ftc(`1e-07`, `%g`, 1e-7),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Exponent: sprintfjs.ExponentSingleDigit}, []formatterTestcase{
+		ftc(`2e+0`, `%e`, 2),
+		ftc(`1.5e+3`, `%e`, 1500),
+		ftc(`-1.5E-3`, `%E`, -0.0015),
+		ftc(`1e+100`, `%e`, 1e100),
+		ftc(`1e-7`, `%g`, 1e-7),
+		ftc(`1.5`, `%g`, 1.5),
+		ftc(`1.2e+4`, `%.1e`, 12345),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Exponent: sprintfjs.ExponentTwoDigits}, []formatterTestcase{
+		ftc(`2e+00`, `%e`, 2),
+		ftc(`1.5e+03`, `%e`, 1500),
+		ftc(`1e+100`, `%e`, 1e100),
+		ftc(`1E+21`, `%G`, 1e21),
+		ftc(`  2.00e+00`, `%10.2e`, 2),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Exponent: sprintfjs.ExponentTwoDigits, EngineeringNotation: true}, []formatterTestcase{
+		ftc(`12.3e+03`, `%.1e`, 12345),
+	})
+}
+
+func TestFormatHexFloat(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`0x1.91eb851eb851fp+1`, `%a`, 3.14),
+		ftc(`0X1.91EB851EB851FP+1`, `%A`, 3.14),
+		ftc(`0x1p+0`, `%a`, 1),
+		ftc(`0x0p+0`, `%a`, 0),
+		ftc(`0x1p-2`, `%a`, 0.25),
+		ftc(`-0x1.8p+1`, `%a`, -3),
+		ftc(`+0x1.8p+1`, `%+a`, 3),
+		ftc(`0x1.92p+1`, `%.2a`, 3.14),
+		ftc(`0x1.8p+1`, `%a`, "3"),
+		ftc(`0x1.8p+1`, `%a`, json.Number("3")),
+		ftc(`  0x1p+0`, `%8a`, 1),
+		ftc(`0x1p+1023`, `%a`, math.Pow(2, 1023)),
+	})
+
+	for _, value := range []interface{}{"abc", []int{1}, map[string]int{}} {
+		if _, err := sprintfjs.Format(`%a`, value); err == nil || !strings.Contains(err.Error(), "expecting number") {
+			t.Errorf("expected %%a of %v to fail, had %v", value, err)
+		}
+	}
+}
+
+type celsius float64
+
+type level int16
+
+func (l level) String() string { return "level" }
+
+func TestFormatOtherNumberTypes(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`-42`, `%d`, int16(-42)),
+		ftc(`+42`, `%+d`, int16(42)),
+		ftc(`42`, `%d`, uint16(42)),
+		ftc(`+42`, `%+d`, uint16(42)),
+		ftc(`4294967254`, `%u`, int16(-42)),
+		ftc(`ff`, `%x`, uintptr(255)),
+		ftc(`-3.5`, `%.1f`, celsius(-3.5)),
+		ftc(`+21.5`, `%+.1f`, celsius(21.5)),
+		ftc(`-2`, `%d`, level(-2)),
+		ftc(`level`, `%s`, level(-2)),
+		ftc(`-1.23e+29`, `%.2e`, huge),
+		ftc(`+1.23e+29`, `%+.2e`, new(big.Int).Neg(huge)),
+		ftc(`+12.5`, `%+.1f`, decimal{"12.5"}),
+		ftc(`-12.5`, `%+.1f`, decimal{"-12.5"}),
+		ftc(`0.1`, `%v`, big.NewFloat(0.1)),
+		ftc(`number`, `%T`, big.NewFloat(0.1)),
+	})
+
+	for _, value := range []interface{}{int16(0), uint16(1), celsius(0), new(big.Int).Neg(huge), json.Number("1e3")} {
+		if !sprintfjs.NewNumber(value).IsPositive() {
+			t.Errorf("expected %v of type %T to be positive", value, value)
+		}
+	}
+	for _, value := range []interface{}{int16(-1), celsius(-0.5), huge, json.Number("-1"), "abc", []int{1}} {
+		if sprintfjs.NewNumber(value).IsPositive() {
+			t.Errorf("expected %v of type %T not to be positive", value, value)
+		}
+	}
+}
+
+func TestFormatBoolAsNumber(t *testing.T) {
+	type flag bool
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`1`, `%d`, true),
+		ftc(`0`, `%i`, false),
+		ftc(`+1`, `%+d`, true),
+		ftc(`1.00`, `%.2f`, true),
+		ftc(`0e+0`, `%e`, false),
+		ftc(`1`, `%x`, true),
+		ftc(`1`, `%b`, true),
+		ftc(`1`, `%u`, true),
+		ftc(`001`, `%03d`, true),
+		ftc(`1`, `%d`, flag(true)),
+		ftc(`true`, `%t`, true),
+		ftc(`false`, `%t`, false),
+		ftc(`true`, `%v`, true),
+		ftc(`boolean`, `%T`, true),
+	})
+
+	for value, expected := range map[bool]int64{true: 1, false: 0} {
+		n := sprintfjs.NewNumber(value)
+		if n.IsNaN() || !n.IsPositive() {
+			t.Errorf("expected %v to be a positive number", value)
+		}
+		if i64, err := n.Int64(); err != nil || i64 != expected {
+			t.Errorf("expected %v to be %d, had %d, %v", value, expected, i64, err)
+		}
+		if f64, err := n.Float64(); err != nil || f64 != float64(expected) {
+			t.Errorf("expected %v to be %d, had %v, %v", value, expected, f64, err)
+		}
+	}
+}
+
+func TestFormatRoundingMode(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`2.35`, `%.2f`, 2.345),
+		ftc(`2.67`, `%.2f`, 2.675),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Rounding: sprintfjs.RoundHalfUp}, []formatterTestcase{
+		ftc(`2.35`, `%.2f`, 2.345),
+		ftc(`2.68`, `%.2f`, 2.675),
+		ftc(`1.01`, `%.2f`, 1.005),
+		ftc(`-2.35`, `%.2f`, -2.345),
+		ftc(`0.13`, `%.2f`, "0.125"),
+		ftc(`3`, `%.0f`, 2.5),
+		ftc(`2.345`, `%.3f`, 2.345),
+		ftc(`2.34500`, `%.5f`, 2.345),
+		ftc(`1,000.00`, `%,.2f`, 999.995),
+		ftc(`1.23e+02`, `%.2e`, 123.45),
+		ftc(`1.24E+02`, `%.2E`, 123.5),
+		ftc(`1.3`, `%.2g`, 1.25),
+		ftc(`0.0002`, `%.1g`, 0.00015),
+		ftc(`1.01`, `%.2f`, decimal{"1.005"}),
+		ftc(`+Inf`, `%+.2f`, math.Inf(1)),
+		ftc(`0.00`, `%.2f`, 0),
+		ftc(`  0.01`, `%6.2f`, 0.005),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Rounding: sprintfjs.RoundHalfDown}, []formatterTestcase{
+		ftc(`2.34`, `%.2f`, 2.345),
+		ftc(`2.35`, `%.2f`, 2.3451),
+		ftc(`-2.34`, `%.2f`, -2.345),
+		ftc(`1.00`, `%.2f`, decimal{"1.005"}),
+		ftc(`2`, `%.0f`, 2.5),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Rounding: sprintfjs.RoundTowardZero}, []formatterTestcase{
+		ftc(`2.34`, `%.2f`, 2.345),
+		ftc(`-2.34`, `%.2f`, -2.349),
+		ftc(`9.9e+02`, `%.1e`, 999),
+		ftc(`0.00`, `%.2f`, 0.009),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Rounding: sprintfjs.RoundAwayFromZero}, []formatterTestcase{
+		ftc(`2.35`, `%.2f`, 2.345),
+		ftc(`2.35`, `%.2f`, 2.341),
+		ftc(`-2.35`, `%.2f`, -2.341),
+		ftc(`0.01`, `%.2f`, 0.0004),
+		ftc(`1e+03`, `%.0e`, 901),
+		ftc(`2.34`, `%.2f`, 2.34),
+		ftc(`1.3e+04`, `%.1e`, 12301),
+	})
+}
+
+func TestFormatPercent(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`12.34%`, `%P`, 0.1234),
+		ftc(`12.34%`, `%.2P`, 0.1234),
+		ftc(`12.3%`, `%.1P`, 0.1234),
+		ftc(`12%`, `%.0P`, 0.1234),
+		ftc(`50%`, `%P`, 0.5),
+		ftc(`100%`, `%P`, 1),
+		ftc(`250.00%`, `%.2P`, "2.5"),
+		ftc(`0.001%`, `%P`, 0.00001),
+		ftc(`-7.5%`, `%P`, -0.075),
+		ftc(`+7.5%`, `%+P`, 0.075),
+		ftc(` 7.5%`, `% P`, 0.075),
+		ftc(`  7.5%`, `%6P`, 0.075),
+		ftc(`7.5%  `, `%-6P`, 0.075),
+		ftc(`-007.5%`, `%07P`, -0.075),
+		ftc(`123,456%`, `%,P`, 1234.56),
+		ftc(`12.35%`, `%.2P`, decimal{"0.123456"}),
+		ftc(`+Inf%`, `%+P`, math.Inf(1)),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de"}, []formatterTestcase{
+		ftc(`12,5%`, `%P`, 0.125),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Rounding: sprintfjs.RoundTowardZero}, []formatterTestcase{
+		ftc(`12.3%`, `%.1P`, 0.12399),
+	})
+
+	if _, err := sprintfjs.Format(`%P`, "abc"); !errors.Is(err, sprintfjs.ErrTypeMismatch) {
+		t.Errorf("expected %%P of a string to fail, had %v", err)
+	}
+	if s := fmt.Sprintf("%.2P", sprintfjs.NewNumber(0.1234)); s != "12.34%" {
+		t.Errorf("expected Number to format as percentage, had %q", s)
+	}
+}
+
+func TestFormatOrdinal(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`0th`, `%n`, 0),
+		ftc(`1st`, `%n`, 1),
+		ftc(`2nd`, `%n`, 2),
+		ftc(`3rd`, `%n`, 3),
+		ftc(`4th`, `%n`, 4),
+		ftc(`11th`, `%n`, 11),
+		ftc(`12th`, `%n`, 12),
+		ftc(`13th`, `%n`, 13),
+		ftc(`21st`, `%n`, 21),
+		ftc(`22nd`, `%n`, 22),
+		ftc(`101st`, `%n`, 101),
+		ftc(`111th`, `%n`, 111),
+		ftc(`1013th`, `%n`, 1013),
+		ftc(`-1st`, `%n`, -1),
+		ftc(`-12th`, `%n`, -12),
+		ftc(`3rd`, `%n`, "3"),
+		ftc(`3rd`, `%n`, 3.0),
+		ftc(`42nd`, `%n`, uint8(42)),
+		ftc(`  3rd`, `%5n`, 3),
+		ftc(`3rd  `, `%-5n`, 3),
+		ftc(`+3rd`, `%+n`, 3),
+		ftc(`1,001st`, `%,n`, 1001),
+		ftc(`You finished 2nd of 10`, `You finished %(rank)n of %(total)d`, map[string]int{"rank": 2, "total": 10}),
+	})
+
+	for _, value := range []interface{}{2.5, "abc", "2.5", math.Inf(1)} {
+		if _, err := sprintfjs.Format(`%n`, value); !errors.Is(err, sprintfjs.ErrTypeMismatch) {
+			t.Errorf("expected %%n of %v to fail, had %v", value, err)
+		}
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`0 B`, `%z`, 0),
+		ftc(`512 B`, `%z`, 512),
+		ftc(`999 B`, `%z`, 999),
+		ftc(`1.0 kB`, `%z`, 1000),
+		ftc(`1.5 kB`, `%z`, 1500),
+		ftc(`2.3 MB`, `%z`, 2300000),
+		ftc(`2.35 MB`, `%.2z`, 2345678),
+		ftc(`2 MB`, `%.0z`, 2345678),
+		ftc(`1.0 MB`, `%z`, 999960),
+		ftc(`1.2 EB`, `%z`, uint64(1200000000000000000)),
+		ftc(`18.4 EB`, `%z`, uint64(math.MaxUint64)),
+		ftc(`1.5 kB`, `%z`, "1500"),
+		ftc(`   1.5 kB`, `%9z`, 1500),
+		ftc(`1.5 kB   `, `%-9z`, 1500),
+		ftc(`1,234.0 EB`, `%,z`, 1.234e21),
+		ftc(`512 B`, `%#z`, 512),
+		ftc(`1.0 KiB`, `%#z`, 1024),
+		ftc(`1.5 KiB`, `%#z`, 1536),
+		ftc(`1000 B`, `%#z`, 1000),
+		ftc(`1.00 MiB`, `%#.2z`, 1048576),
+		ftc(`1.0 MiB`, `%#z`, 1048575),
+	})
+	runFormatterTests(t, &sprintfjs.Formatter{Locale: "de"}, []formatterTestcase{
+		ftc(`1,5 kB`, `%z`, 1500),
+	})
+
+	for _, value := range []interface{}{-1, "abc", math.NaN(), math.Inf(1)} {
+		if _, err := sprintfjs.Format(`%z`, value); !errors.Is(err, sprintfjs.ErrTypeMismatch) {
+			t.Errorf("expected %%z of %v to fail, had %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package sprintfjs
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// KeyValue is an entry of an `OrderedMap`.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedMap is a JSON object that keeps the order of its keys.
+// Like JavaScript objects, %j renders its entries in the given order, e.g.
+// Format("%j", OrderedMap{{"b", 1}, {"a", 2}}) yields {"b":1,"a":2},
+// whereas Go maps are always rendered with sorted keys.
+// Named placeholders look up keys in it like in a map, e.g. %(a)d.
+type OrderedMap []KeyValue
+
+// Get returns the value of the first entry with the key `key`.
+func (m OrderedMap) Get(key string) (interface{}, bool) {
+	for _, kv := range m {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON encodes the entries as a JSON object in their order.
+// HTML characters are left to the encoder of %j, see `Formatter.JSONNoEscapeHTML`.
+func (m OrderedMap) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, kv := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(kv.Key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // drop the newline of Encode
+		buf.WriteByte(':')
+		if err := encoder.Encode(kv.Value); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
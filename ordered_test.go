@@ -0,0 +1,35 @@
+package sprintfjs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatOrderedMap(t *testing.T) {
+	user := sprintfjs.OrderedMap{
+		{"name", "alice"},
+		{"age", 42},
+		{"address", sprintfjs.OrderedMap{{"zip", "10115"}, {"city", "Berlin"}}},
+		{"html", "<b>"},
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`{"name":"alice","age":42,"address":{"zip":"10115","city":"Berlin"},"html":"\u003cb\u003e"}`, `%j`, user),
+		ftc("{\n  \"zip\": \"10115\",\n  \"city\": \"Berlin\"\n}", `%2j`, user[2].Value),
+		ftc(`{}`, `%j`, sprintfjs.OrderedMap{}),
+		ftc(`null`, `%j`, sprintfjs.OrderedMap(nil)),
+		ftc(`[{"b":1,"a":2}]`, `%j`, []interface{}{sprintfjs.OrderedMap{{"b", 1}, {"a", 2}}}),
+		ftc(`alice (42) lives in Berlin`, `%(name)s (%(age)d) lives in %(address.city)s`, user),
+		ftc(`<nil>`, `%(missing)v`, user),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{JSONNoEscapeHTML: true}, []formatterTestcase{
+		ftc(`{"html":"<b>"}`, `%j`, sprintfjs.OrderedMap{{"html", "<b>"}}),
+	})
+
+	if _, err := json.Marshal(sprintfjs.OrderedMap{{"ch", make(chan int)}}); err == nil {
+		t.Error("expected an unsupported value to fail")
+	}
+}
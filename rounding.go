@@ -0,0 +1,143 @@
+package sprintfjs
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode is how %e, %f and %g round numbers to their precision, see `Formatter.Rounding`.
+// Except for `RoundDefault` the modes round the shortest decimal representation of a number, i.e. the digits
+// shown by %v, so that "%.2f" of 2.345 rounds the decimal 2.345 rather than its binary approximation.
+type RoundingMode int
+
+const (
+	// RoundDefault rounds the exact binary value to the nearest like `strconv.FormatFloat`,
+	// e.g. "%.2f" of 2.345 yields 2.35 but of 2.675 yields 2.67, as neither is exactly representable.
+	// Decimal types, e.g. *big.Rat, round halves away from zero.
+	RoundDefault RoundingMode = iota
+	// RoundHalfUp rounds to the nearest and halves away from zero, e.g. "%.2f" of 2.345 yields 2.35 and of -2.345 yields -2.35.
+	RoundHalfUp
+	// RoundHalfDown rounds to the nearest and halves toward zero, e.g. "%.2f" of 2.345 yields 2.34 and of 2.3451 yields 2.35.
+	RoundHalfDown
+	// RoundTowardZero truncates, e.g. "%.2f" of 2.345 yields 2.34 and of -2.349 yields -2.34.
+	RoundTowardZero
+	// RoundAwayFromZero rounds up the magnitude, e.g. "%.2f" of 2.345 yields 2.35 and of 2.341 yields 2.35.
+	RoundAwayFromZero
+)
+
+// roundedDecimal is a number rounded by `round` or multiplied by `Number.Percent`. Like other decimal types it is formatted without converting
+// to binary floating point, see `decimalString`.
+type roundedDecimal string
+
+func (d roundedDecimal) String() string {
+	return string(d)
+}
+
+func (d roundedDecimal) Float64() (float64, bool) {
+	f64, err := strconv.ParseFloat(string(d), 64)
+	return f64, err == nil
+}
+
+// round rounds a number for a float verb, i.e. to `prec` fractional digits for f, `prec`+1 significant digits for e
+// and `prec` significant digits for g. Numbers that need no rounding, as well as NaN and infinity, are returned as they are.
+func (mode RoundingMode) round(n Number, verb byte, prec int) Number {
+	if mode == RoundDefault || prec < 0 {
+		return n
+	}
+
+	s, ok := decimalString(n.value)
+	if !ok {
+		f64, err := n.Float64()
+		if err != nil {
+			return n
+		}
+		s = strconv.FormatFloat(f64, 'e', -1, 64)
+	}
+
+	neg, digits, exp, ok := splitDecimal(s)
+	if !ok {
+		return n // NaN or infinity
+	}
+
+	drop := 0 // number of trailing digits to round off
+	switch verb {
+	case 'f':
+		drop = -prec - exp
+	case 'e', 'E':
+		drop = len(digits) - (prec + 1)
+	case 'g', 'G':
+		if prec == 0 {
+			prec = 1
+		}
+		drop = len(digits) - prec
+	}
+	if drop <= 0 || digits == "0" {
+		return n
+	}
+
+	d, _ := new(big.Int).SetString(digits, 10)
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(drop)), nil)
+	q, r := new(big.Int).QuoRem(d, m, new(big.Int))
+	half := new(big.Int).Lsh(r, 1).Cmp(m) // compares the remainder to half of the rounded off unit
+
+	up := false
+	switch mode {
+	case RoundHalfUp:
+		up = half >= 0
+	case RoundHalfDown:
+		up = half > 0
+	case RoundAwayFromZero:
+		up = r.Sign() != 0
+	}
+	if up {
+		q.Add(q, big.NewInt(1))
+	}
+
+	return NewNumber(roundedDecimal(joinDecimal(neg, q.String(), exp+drop)))
+}
+
+// splitDecimal splits a decimal number, e.g. "-1.25e-3", into its sign, its digits without leading zeros
+// and the exponent of the last digit, e.g. true, "125" and -5.
+func splitDecimal(s string) (neg bool, digits string, exp int, ok bool) {
+	if neg = strings.HasPrefix(s, "-"); neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return false, "", 0, false
+		}
+		s, exp = s[:i], e
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, exp = s[:i]+s[i+1:], exp-(len(s)-i-1)
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false, "", 0, false // e.g. "Inf" or hexadecimal
+		}
+	}
+	digits = strings.TrimLeft(s, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return neg, digits, exp, true
+}
+
+// joinDecimal is the reverse of `splitDecimal` and returns a decimal number without exponent, e.g. "-0.00125".
+func joinDecimal(neg bool, digits string, exp int) string {
+	switch {
+	case digits == "0":
+	case exp >= 0:
+		digits += strings.Repeat("0", exp)
+	case -exp < len(digits):
+		digits = digits[:len(digits)+exp] + "." + digits[len(digits)+exp:]
+	default:
+		digits = "0." + strings.Repeat("0", -exp-len(digits)) + digits
+	}
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
@@ -0,0 +1,28 @@
+package sprintfjs
+
+import (
+	"fmt"
+)
+
+// SafeFormat is like `Format` but never fails and never panics.
+// It is meant for untrusted format strings and values.
+// If formatting fails, the format string is returned followed by a `%!(ERROR=...)` marker.
+// If formatting panics, the format string is returned followed by a `%!(PANIC=...)` marker.
+func SafeFormat(format string, args ...interface{}) string {
+	return defaultFormatter.SafeFormat(format, args...)
+}
+
+// SafeFormat is like `Format` but never fails and never panics. See `SafeFormat`.
+func (f *Formatter) SafeFormat(format string, args ...interface{}) (formatted string) {
+	defer func() {
+		if r := recover(); r != nil {
+			formatted = format + fmt.Sprintf("%%!(PANIC=%v)", r)
+		}
+	}()
+
+	formatted, err := f.Format(format, args...)
+	if err != nil {
+		return format + "%!(ERROR=" + err.Error() + ")"
+	}
+	return formatted
+}
@@ -0,0 +1,51 @@
+package sprintfjs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string { panic("boom") }
+
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalJSON() ([]byte, error) { panic("boom") }
+
+func TestSafeFormat(t *testing.T) {
+	var nilFunc func() string
+
+	type testcase struct {
+		Name     string
+		Expected string
+		Format   string
+		Args     []interface{}
+	}
+	testcases := []testcase{
+		{"valid", `Hello world!`, `Hello %s!`, []interface{}{"world"}},
+		{"func", `Hello world!`, `Hello %s!`, []interface{}{func() string { return "world" }}},
+		{"variadic func", `Hello world!`, `Hello %s!`, []interface{}{func(...int) string { return "world" }}},
+		{"parse error", `%!(ERROR=`, `%y`, nil},
+		{"missing argument", `%!(ERROR=`, `%s`, nil},
+		{"nil func", `%!(ERROR=`, `%s`, []interface{}{nilFunc}},
+		{"func with arguments", `%!(ERROR=`, `%s`, []interface{}{strings.ToUpper}},
+		{"func without result", `%!(ERROR=`, `%s`, []interface{}{func() {}}},
+		{"panicking func", `%!(PANIC=boom)`, `%s`, []interface{}{func() string { panic("boom") }}},
+		{"panicking marshaler", `%!(PANIC=boom)`, `%j`, []interface{}{panickingMarshaler{}}},
+		{"panicking stringer", `PANIC=`, `%s`, []interface{}{panickingStringer{}}},
+		{"nil map", `%!(ERROR=`, `%(a.b)s`, []interface{}{map[string]interface{}{"a": nil}}},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			actual := sprintfjs.SafeFormat(tc.Format, tc.Args...)
+			if !strings.Contains(actual, tc.Expected) {
+				t.Fatalf("expected %q to contain %q", actual, tc.Expected)
+			}
+		})
+	}
+}
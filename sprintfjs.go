@@ -3,94 +3,203 @@
 package sprintfjs
 
 import (
+	"database/sql/driver"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"net"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
-	// reNotString    = regexp.MustCompile("[^s]")
-	// reNotBool      = regexp.MustCompile("[^t]")
-	reNotType      = regexp.MustCompile("[^T]")
-	reNotPrimitive = regexp.MustCompile("[^v]")
-	reNumericArg   = regexp.MustCompile("[bcdiefguxX]")
-	reNotJSON      = regexp.MustCompile("[^j]")
-	reJSON         = regexp.MustCompile("[j]")
-	reSign         = regexp.MustCompile("^[+-]")
-	reNumber       = regexp.MustCompile("[diefg]")
-	reText         = regexp.MustCompile("^[^\x25]+")
-	reModulo       = regexp.MustCompile("^\x25{2}")
-	rePlaceholder  = regexp.MustCompile(`^\x25(?:([1-9]\d*)\$|\(([^)]+)\))?(\+)?(0|'[^$])?(-)?(\d+)?(?:\.(\d+))?([b-gijostTuvxX])`)
-	reKey          = regexp.MustCompile(`^(?i:([a-z_][a-z_\d]*))`)
-	reKeyAccess    = regexp.MustCompile(`^\.(?i:([a-z_][a-z_\d]*))`)
-	reIndexAccess  = regexp.MustCompile(`^\[(\d+)\]`)
+	reText        = regexp.MustCompile("^[^\x25]+")
+	reModulo      = regexp.MustCompile("^\x25{2}")
+	rePlaceholder = regexp.MustCompile(`^\x25(?:([1-9]\d*)\$|\(([^)]+)\))?([+ ]+)?(#)?(,)?([\^_~])?(0|'[^$])?([-=])?(\d+|\*)?(?:\.(\d+|\*))?(` + verbs + `)(\?)?`)
+	reVerb        = regexp.MustCompile(`^` + verbs + `$`)
+	reKey         = regexp.MustCompile(`^(?i:([a-z_][a-z_\d]*))`)
+	reKeyAccess   = regexp.MustCompile(`^\.(?i:([a-z_][a-z_\d]*))`)
+	reIndexAccess = regexp.MustCompile(`^\[(\d+|\*)\]`)
+	reWhitespace  = regexp.MustCompile(`\s+`)
+)
+
+// verbs matches the verbs of placeholders
+const verbs = `[a-gijnopqstTuvxXzACEGHPRU]`
+
+// Verbs by how they treat their argument
+const (
+	numericArgVerbs = "abcdiefgnuxXzAEGP" // require a number
+	numberVerbs     = "adiefgnAEGP"       // render the sign of a number
+)
+
+// isNumericArgType reports whether the verb `typ` requires a number.
+func isNumericArgType(typ string) bool {
+	return len(typ) == 1 && strings.IndexByte(numericArgVerbs, typ[0]) >= 0
+}
+
+// isNumberType reports whether the verb `typ` renders the sign of a number.
+func isNumberType(typ string) bool {
+	return len(typ) == 1 && strings.IndexByte(numberVerbs, typ[0]) >= 0
+}
+
+// submatch indices of `rePlaceholder`
+const (
+	phParamNo = iota + 1
+	phKeys
+	phSign
+	phAlternate
+	phGrouping
+	phCase
+	phPad
+	phAlign
+	phWidth
+	phPrecision
+	phType
+	phOmit
 )
 
 // ASTNode is a node in the abstract syntax tree
 type ASTNode struct {
-	Text        string
-	Placeholder string
-	ParamNo     int
-	Keys        []string
-	Sign        string
-	Pad         string
-	Align       string
-	Width       int
-	Precision   string
-	Type        string
+	Text             string
+	Placeholder      string
+	ParamNo          int
+	Keys             []string // property names, "[n]" for index access, "[*]" for a wildcard
+	Sign             string   // "+", " " or empty
+	Alternate        string
+	Grouping         string
+	Case             string // "^" upper case, "_" lower case or "~" title case
+	Pad              string
+	Align            string
+	Width            int
+	DynamicWidth     bool // width * is read from the argument at the cursor
+	Precision        string
+	DynamicPrecision bool // precision .* is read from the argument at the cursor
+	Type             string
+	Omit             string
+	Layout           string // Go reference layout of a time.Time, e.g. "2006-01-02" for %(date|2006-01-02)s
+	Default          string // value of a missing or null key, e.g. "guest" for %(name:guest)s
+	HasDefault       bool   // the named placeholder has a default, which may be empty, e.g. %(name:)s
+	Offset           int    // byte offset of the node in the format string
 }
 
 // AST is an abstract syntax tree
 type AST []ASTNode
 
+// DefaultMaxWidth is the largest width or precision accepted by `Parse`.
+const DefaultMaxWidth = 1 << 20
+
 // Parse parses a format string into an abstract syntax tree.
 func Parse(format string) (AST, error) {
+	return defaultFormatter.Parse(format)
+}
+
+// MustParse is like `Parse` but panics if the format string cannot be parsed.
+// It simplifies safe initialization of global variables holding parsed format strings.
+func MustParse(format string) AST {
+	ast, err := Parse(format)
+	if err != nil {
+		panic(err)
+	}
+	return ast
+}
+
+// Parse parses a format string into an abstract syntax tree.
+// Widths and precisions larger than `MaxWidth` are rejected.
+func (f *Formatter) Parse(format string) (AST, error) {
+	if isConstant(format) {
+		if format == "" {
+			return AST{}, nil
+		}
+		return AST{{Text: format}}, nil
+	}
+
 	ast := AST{}
-	argNames := 0
+	offset := 0
+	input := format
+	fail := func(text string, err *Error) (AST, error) {
+		return nil, &ParseError{Format: input, Offset: offset, Text: text, Err: err.at(text, 0)}
+	}
 
 	for len(format) > 0 {
 		l := 0
 		if match := reText.FindAllString(format, 1); len(match) > 0 {
-			ast = append(ast, ASTNode{Text: match[0]})
+			ast = append(ast, ASTNode{Text: match[0], Offset: offset})
 			l = len(match[0])
 		} else if match := reModulo.FindAllString(format, 1); len(match) > 0 {
-			ast = append(ast, ASTNode{Text: "%"})
+			ast = append(ast, ASTNode{Text: "%", Offset: offset})
 			l = len(match[0])
 		} else if ms := rePlaceholder.FindAllStringSubmatch(format, 1); len(ms) > 0 {
 			m := ms[0]
 			l = len(m[0])
 			node := ASTNode{
 				Placeholder: m[0],
-				Sign:        m[3],
-				Pad:         m[4],
-				Align:       m[5],
-				Precision:   m[7],
-				Type:        m[8],
+				Sign:        signFlag(m[phSign]),
+				Alternate:   m[phAlternate],
+				Grouping:    m[phGrouping],
+				Case:        m[phCase],
+				Pad:         m[phPad],
+				Align:       m[phAlign],
+				Precision:   m[phPrecision],
+				Type:        m[phType],
+				Omit:        m[phOmit],
+				Offset:      offset,
 			}
 
-			if m[1] != "" {
-				paramNo, err := strconv.Atoi(m[1])
+			if m[phParamNo] != "" {
+				paramNo, err := strconv.Atoi(m[phParamNo])
 				if err != nil {
-					return nil, fmt.Errorf("[sprintf] failed to parse positional argument %q: %v", m[1], err)
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] failed to parse positional argument %q: %v", m[phParamNo], err))
 				}
 				node.ParamNo = paramNo
 			}
-			if m[6] != "" {
-				width, err := strconv.Atoi(m[6])
+			if m[phWidth] == "*" {
+				node.DynamicWidth = true
+			} else if m[phWidth] != "" {
+				width, err := strconv.Atoi(m[phWidth])
 				if err != nil {
-					return nil, fmt.Errorf("[sprintf] failed to parse width %q: %v", m[6], err)
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] failed to parse width %q: %v", m[phWidth], err))
+				}
+				if width > f.maxWidth() {
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] width %d exceeds the maximum of %d", width, f.maxWidth()))
 				}
 				node.Width = width
 			}
+			if m[phPrecision] == "*" {
+				node.Precision, node.DynamicPrecision = "", true
+			} else if m[phPrecision] != "" {
+				precision, err := strconv.Atoi(m[phPrecision])
+				if err != nil {
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] failed to parse precision %q: %v", m[phPrecision], err))
+				}
+				if precision > f.maxWidth() {
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] precision %d exceeds the maximum of %d", precision, f.maxWidth()))
+				}
+			}
 
-			if m[2] != "" {
-				argNames |= 1
+			if m[phKeys] != "" {
 				keys := []string{}
-				keyNames := m[2]
+				keyNames := m[phKeys]
+				if i := strings.Index(keyNames, "|"); i >= 0 {
+					keyNames, node.Layout = keyNames[:i], keyNames[i+1:]
+					if node.Layout == "" {
+						return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] missing time layout in %q", node.Placeholder))
+					}
+					if node.Type != "s" && node.Type != "v" {
+						return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] time layout of %q requires the type s or v", node.Placeholder))
+					}
+				}
+				if i := strings.Index(keyNames, ":"); i >= 0 {
+					keyNames, node.Default, node.HasDefault = keyNames[:i], keyNames[i+1:], true
+				}
 
 				if ms := reKey.FindAllStringSubmatch(keyNames, 1); len(ms) > 0 {
 					m := ms[0]
@@ -106,32 +215,31 @@ func Parse(format string) (AST, error) {
 							keys = append(keys, ms[0][1])
 							keyLen = len(ms[0][0])
 						} else if ms := reIndexAccess.FindAllStringSubmatch(keyNames, 1); len(ms) > 0 {
+							keys = append(keys, ms[0][0])
 							keyLen = len(ms[0][0])
 						} else {
-							return nil, errors.New("[sprintf] failed to parse named argument key")
+							return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] failed to parse named argument key"))
 						}
 					}
 				} else {
-					return nil, errors.New("[sprintf] failed to parse named argument key")
+					return fail(node.Placeholder, errorf(ErrInvalidPlaceholder, "[sprintf] failed to parse named argument key"))
 				}
 				node.Keys = keys
-			} else {
-				argNames |= 2
 			}
-
-			if argNames == 3 {
-				return nil, errors.New("[sprintf] mixing positional and named placeholders is not (yet) supported")
+			if !f.verbAllowed(node.Type) {
+				return fail(node.Placeholder, errorf(ErrVerbNotAllowed, "[sprintf] verb %q of %q is not allowed", node.Type, node.Placeholder))
 			}
 
 			ast = append(ast, node)
 		} else {
-			return nil, errors.New("[sprintf] unexpected placeholder")
+			return fail(invalidPlaceholder(format), errorf(ErrInvalidPlaceholder, "[sprintf] unexpected placeholder"))
 		}
 
 		if l >= len(format) {
 			break
 		}
 		format = format[l:]
+		offset += l
 	}
 	return ast, nil
 }
@@ -141,155 +249,451 @@ func Parse(format string) (AST, error) {
 //  The placeholders in the format string are marked by % and are followed by one or more of these elements, in this order:
 //  * An optional number followed by a $ sign that selects which argument index to use for the value.
 //    If not specified, arguments will be placed in the same order as the placeholders in the input string.
+//  * Alternatively an optional key in parenthesis that selects a property of the context argument, e.g. %(user.name)s:
+//    * The context argument is the argument at the implicit cursor, which only implicit placeholders advance,
+//      e.g. "%s lives in %(city)s" reads the city from the second argument, see `Formatter.ContextArg`.
+//    * Properties are map keys or exported struct fields, matching their json tag or their name ignoring case.
+//    * [n] indexes slices and maps with integer keys, e.g. %(matrix[1][2])s; [*] collects a key of each element, e.g. %(users[*].name)s.
+//    * A missing last key yields nil, a missing key in the middle of the path is an error.
+//    * A | followed by a Go reference layout formats a time.Time with s or v, e.g. %(created|2006-01-02)s.
+//    * A : followed by text is the default for a missing or null value, e.g. %(name:guest)s, formatted like a string argument.
+//      It also replaces a missing key in the middle of the path and an index out of range; it cannot contain ) or |.
 //  * An optional + sign that forces to preceed the result with a plus or minus sign on numeric values.
-//    By default, only the - sign is used on negative numbers.
+//    By default, only the - sign is used on negative numbers. A space puts a space before non-negative numbers instead.
+//  * An optional # sign that selects the alternate form: numbers with the prefix of their base, e.g. 0x,
+//    Go syntax for v and binary units for z.
+//  * An optional , sign that groups the digits of decimal numbers, e.g. 1,234,567, as the `Formatter` locale does.
+//  * An optional case flag that converts the result of s to upper case with ^, lower case with _ or title case with ~,
+//    as the `Formatter` locale does. Padding characters are kept as they are.
 //  * An optional padding specifier that says what character to use for padding (if specified).
 //    Possible values are 0 or any other character precedeed by a ' (single quote). The default is to pad with spaces.
-//  * An optional - sign, that causes sprintf to left-align the result of this placeholder.
-//    The default is to right-align the result.
-//  * An optional number, that says how many characters the result should have.
+//    Like sprintf.js 0 also pads strings, see `Formatter.NoZeroPadStrings`.
+//  * An optional - sign, that causes sprintf to left-align the result of this placeholder, or an = sign that centers it.
+//    The default is to right-align the result, see `Formatter.DefaultAlign`.
+//  * An optional number, that says how many characters (runes) the result should have.
 //    If the value to be returned is shorter than this number, the result will be padded.
+//    A * reads the width from the argument at the implicit cursor; a negative width left-aligns the result.
 //    When used with the j (JSON) type specifier, the padding length specifies the tab size used for indentation.
 //  * An optional precision modifier, consisting of a . (dot) followed by a number, that says how many digits should be displayed for floating point numbers.
+//    A .* reads the precision from the argument at the implicit cursor.
 //    When used with the g type specifier, it specifies the number of significant digits.
-//    When used on a string, it causes the result to be truncated.
+//    When used on a string, it causes the result to be truncated to that many runes.
 //  * A type specifier that can be any of:
 //    * % — yields a literal % character
+//    * a and A — yield a float as hexadecimal floating point number, e.g. 0x1.91eb851eb851fp+1
 //    * b — yields an integer as a binary number
-//    * c — yields an integer as the character with that ASCII value
+//    * c — yields an integer as the character with that code point and a string as its first character
 //    * d or i — yields an integer as a signed decimal number
-//    * e — yields a float using scientific notation
-//    * u — yields an integer as an unsigned decimal number
+//    * e — yields a float using scientific notation, see `Formatter.Exponent`
+//    * u — yields an integer as an unsigned decimal number; like for o, x and X negative integers yield their
+//      two's complement, 32 bits for values that fit into 32 bits, e.g. 4294967294 for -2, and 64 bits otherwise
 //    * f — yields a float as is; see notes on precision above
 //    * g — yields a float as is; see notes on precision above
+//    * E and G — like e and g with an upper-case exponent
+//    * n — yields an integer as an English ordinal number, e.g. 22nd
 //    * o — yields an integer as an octal number
-//    * s — yields a string as is
-//    * t — yields true or false
-//    * T — yields the type of the argument1
-//    * v — yields the primitive value of the specified argument
+//    * p — yields the address of a pointer, slice, map, channel or function
+//    * q — yields a string as a double-quoted Go string literal and an integer as a single-quoted Go character literal
+//    * s — yields a string as is, the contents of an io.Reader and the text of an encoding.TextMarshaler or fmt.Stringer
+//    * t — yields true or false; other values convert like in JavaScript
+//    * T — yields the type of the argument1, e.g. number, string or duration
+//    * v — yields the primitive value of the specified argument; with # the value in Go syntax
 //    * x — yields an integer as a hexadecimal number (lower-case)
 //    * X — yields an integer as a hexadecimal number (upper-case)
+//    * z — yields a number of bytes in units of 1000, e.g. 1.5 kB, or with # of 1024, e.g. 1.5 KiB
 //    * j — yields a JavaScript object or array as a JSON encoded string
+//    * C — yields a value as a table cell of exactly width characters, see `Formatter.CellOverflow`
+//    * H — yields a hash of a string as hexadecimal digest, see `Formatter.HashAlgorithm`
+//    * P — yields a number as a percentage, e.g. %.1P of 0.1234 yields 12.3%
+//    * R — yields a time.Time relative to now in words, e.g. "3 minutes ago"
+//    * U — yields 16 bytes as a UUID
+//  * An optional ? sign that omits the placeholder along with its adjacent text if the value is nil or empty,
+//    e.g. Format("%s (%s?)", "a", nil) yields "a".
 func Format(format string, args ...interface{}) (string, error) {
-	ast, err := Parse(format)
+	return defaultFormatter.Format(format, args...)
+}
+
+// Vformat is like `Format` with the arguments given as a slice,
+// which avoids accidentally passing the slice as a single argument.
+func Vformat(format string, args []interface{}) (string, error) {
+	return defaultFormatter.Format(format, args...)
+}
+
+// MustFormat is like `Format` but panics if formatting fails.
+// Use it with format strings known to be valid and arguments known to match, like `regexp.MustCompile`.
+func MustFormat(format string, args ...interface{}) string {
+	formatted, err := Format(format, args...)
 	if err != nil {
-		return "", err
+		panic(err)
 	}
-	return FormatAST(ast, args...)
+	return formatted
+}
+
+// FormatStrings is like `Format` for arguments given as strings, e.g. command line arguments.
+// Each argument is coerced as required by its placeholder: numeric placeholders parse numbers and
+// %t parses booleans like `strconv.ParseBool`, so "false" yields false.
+func FormatStrings(format string, args ...string) (string, error) {
+	return defaultFormatter.FormatStrings(format, args...)
 }
 
 // FormatAST formats an abstract syntax tree returned by `Parse`.
 func FormatAST(ast AST, args ...interface{}) (string, error) {
-	cursor := 0
+	return defaultFormatter.FormatAST(ast, args...)
+}
 
-	output := strings.Builder{}
+// Fformat formats like `Format` but writes the result to `w` instead of returning it.
+// It returns the number of bytes written and any write error, e.g. `io.ErrShortWrite`.
+// On errors `w` may have received part of the output.
+func Fformat(w io.Writer, format string, args ...interface{}) (int, error) {
+	return defaultFormatter.Fformat(w, format, args...)
+}
 
-	for _, node := range ast {
-		if node.Text != "" {
-			output.WriteString(node.Text)
-		} else {
-			arg, nextCursor, err := argumentValue(node, args, cursor)
-			if err != nil {
-				return "", err
-			}
-			cursor = nextCursor
+// FformatAST formats an abstract syntax tree returned by `Parse` to `w`. See `Fformat`.
+func FformatAST(w io.Writer, ast AST, args ...interface{}) (int, error) {
+	return defaultFormatter.FformatAST(w, ast, args...)
+}
 
-			f, err := formatPlaceholder(node, arg)
-			if err != nil {
-				return "", err
-			}
+// AppendFormat formats like `Format` and appends the result to `dst`, returning the extended buffer.
+// On errors it returns `dst` unchanged, although bytes beyond its length may have been overwritten.
+func AppendFormat(dst []byte, format string, args ...interface{}) ([]byte, error) {
+	return defaultFormatter.AppendFormat(dst, format, args...)
+}
 
-			if _, err = output.WriteString(f); err != nil {
-				return "", err
-			}
-		}
-	}
-	return output.String(), nil
+// AppendFormatAST formats an abstract syntax tree returned by `Parse` and appends the result to `dst`. See `AppendFormat`.
+func AppendFormatAST(dst []byte, ast AST, args ...interface{}) ([]byte, error) {
+	return defaultFormatter.AppendFormatAST(dst, ast, args...)
 }
 
-func argumentValue(ph ASTNode, args []interface{}, cursor int) (arg interface{}, nextCursor int, err error) {
+func (f *Formatter) argumentValue(ph ASTNode, args []interface{}, cursor int) (arg interface{}, nextCursor int, err error) {
 	if ph.Keys != nil { // keyword argument
-
-		if cursor < 0 || cursor >= len(args) {
-			return nil, cursor, fmt.Errorf("[sprintf] Implicit argument index is out of range. Not enough arguments, need at least %d", cursor+1)
+		if f.ContextArg > 0 {
+			if f.ContextArg > len(args) {
+				return nil, cursor, errorf(ErrTooFewArgs, "[sprintf] Context argument index %d is out of range", f.ContextArg).at(ph.Placeholder, f.ContextArg)
+			}
+			arg, err = f.lookup(args[f.ContextArg-1], ph.Keys, ph.Keys)
+			return arg, cursor, err
 		}
 
-		arg = args[cursor]
-
-		for _, key := range ph.Keys {
-			if arg == nil {
-				return nil, cursor, fmt.Errorf("[sprintf] Cannot access property %q of nil in %q", key, strings.Join(ph.Keys, "."))
-			}
-			marg, ok := arg.(map[string]interface{})
-			if !ok {
-				return nil, cursor, fmt.Errorf("[sprintf] Cannot access property %q in value of type %T", key, arg)
-			}
-			arg = marg[key]
+		if cursor < 0 || cursor >= len(args) {
+			return nil, cursor, errorf(ErrTooFewArgs, "[sprintf] Implicit argument index is out of range. Not enough arguments, need at least %d", cursor+1).at(ph.Placeholder, cursor+1)
 		}
 
-		return arg, cursor, nil
+		arg, err = f.lookup(args[cursor], ph.Keys, ph.Keys)
+		return arg, cursor, err
 	}
 
 	if ph.ParamNo != 0 { // positional argument (explicit)
 		if ph.ParamNo < 1 || ph.ParamNo > len(args) {
-			return nil, cursor, fmt.Errorf("[sprintf] Positional argument index %d is out of range", ph.ParamNo)
+			return nil, cursor, errorf(ErrTooFewArgs, "[sprintf] Positional argument index %d is out of range", ph.ParamNo).at(ph.Placeholder, ph.ParamNo)
 		}
 		return args[ph.ParamNo-1], cursor, nil
 	}
 
 	// positional argument (implicit)
 	if cursor < 0 || cursor >= len(args) {
-		return nil, cursor, fmt.Errorf("[sprintf] Implicit argument index is out of range. Not enough arguments, need at least %d", cursor+1)
+		return nil, cursor, errorf(ErrTooFewArgs, "[sprintf] Implicit argument index is out of range. Not enough arguments, need at least %d", cursor+1).at(ph.Placeholder, cursor+1)
 	}
 	return args[cursor], cursor + 1, nil
 }
 
-func formatPlaceholder(ph ASTNode, value interface{}) (formatted string, err error) {
-	if reNotType.MatchString(ph.Type) && reNotPrimitive.MatchString(ph.Type) && isFunc(value) {
-		value = reflect.ValueOf(value).Call([]reflect.Value{})
+// placeholderValue returns the value to format for a placeholder:
+// it applies the null policy, reads readers, dereferences pointers and calls functions.
+// If `isText` is true the placeholder renders `text` instead, e.g. `NilText`.
+func (f *Formatter) placeholderValue(ph ASTNode, value interface{}) (v interface{}, text string, isText bool, err error) {
+	if isNull(value) {
+		switch {
+		case ph.Type == "j":
+			value = nil // always valid JSON
+		case f.NullAsZero && isNumericArgType(ph.Type) && ph.Type != "c":
+			value = 0
+		case f.NilText != "":
+			return nil, f.alignedPad(f.NilText, ph.Width, f.padChar(ph), f.align(ph), "", ""), true, nil
+		case f.DereferencePointers && value != nil:
+			return nil, "", false, errorf(ErrTypeMismatch, "[sprintf] cannot format nil pointer as %q", ph.Placeholder)
+		}
+	}
+
+	if r, ok := value.(io.Reader); ok && ph.Type == "s" {
+		if value, err = f.read(r, ph.Precision); err != nil {
+			return nil, "", false, wrapf(ErrTypeMismatch, err, "[sprintf] failed to read value for %q: %v", ph.Placeholder, err)
+		}
+	}
+
+	if f.DereferencePointers && ph.Type != "p" {
+		var isNil bool
+		if value, isNil = dereference(value); isNil {
+			if f.NilText == "" {
+				return nil, "", false, errorf(ErrTypeMismatch, "[sprintf] cannot format nil pointer as %q", ph.Placeholder)
+			}
+			return nil, f.alignedPad(f.NilText, ph.Width, f.padChar(ph), f.align(ph), "", ""), true, nil
+		}
+	}
+
+	if ph.Type != "T" && ph.Type != "v" && ph.Type != "p" && isFunc(value) {
+		if value, err = callFunc(value); err != nil {
+			return nil, "", false, err
+		}
+	}
+
+	if b, ok := value.(bool); ok && f.BoolAsNumber && ph.Type != "t" && ph.Type != "T" && ph.Type != "j" {
+		value = "0"
+		if b {
+			value = "1"
+		}
+	}
+	return value, "", false, nil
+}
+
+func (f *Formatter) formatPlaceholder(ph ASTNode, value interface{}) (formatted string, err error) {
+	value, text, isText, err := f.placeholderValue(ph, value)
+	if err != nil || isText {
+		return text, err
 	}
 
 	numberValue := NewNumber(value)
-	if reNumericArg.MatchString(ph.Type) && numberValue.IsNaN() {
-		return "", fmt.Errorf("[sprintf] expecting number but found %T", value)
+	_, isString := value.(string)
+	if isNumericArgType(ph.Type) && numberValue.IsNaN() && !(ph.Type == "c" && isString) {
+		return "", errorf(ErrTypeMismatch, "[sprintf] expecting number but found %T", value)
 	}
 
 	formattedValue := ""
 	switch ph.Type[0] {
 	case 'c':
-		formattedValue = fmt.Sprintf("%c", value)
-	case 'b', 'd', 'i', 'u', 'e', 'f', 'g', 'o', 'x', 'X':
+		formattedValue, err = formatChar(value)
+	case 'a', 'e', 'f', 'g', 'A', 'E', 'G':
+		var precision string
+		if precision, err = f.floatPrecision(ph.Precision); err == nil {
+			if (ph.Type == "e" || ph.Type == "E") && f.EngineeringNotation {
+				formattedValue, err = formatEngineering(numberValue, precision)
+				if ph.Type == "E" {
+					formattedValue = strings.ToUpper(formattedValue)
+				}
+			} else {
+				if prec, perr := strconv.Atoi(precision); perr == nil {
+					numberValue = f.Rounding.round(numberValue, ph.Type[0], prec)
+				}
+				formattedValue, err = formatWithPrecision(ph.Type, precision, numberValue)
+			}
+			if ph.Type != "a" && ph.Type != "A" {
+				formattedValue = f.Exponent.apply(formattedValue)
+			}
+		}
+	case 'n':
+		formattedValue, err = formatOrdinal(numberValue)
+	case 'z':
+		var precision string
+		if precision, err = f.floatPrecision(ph.Precision); err == nil {
+			formattedValue, err = f.formatByteSize(numberValue, precision, ph.Alternate != "")
+		}
+	case 'P':
+		var precision string
+		if precision, err = f.floatPrecision(ph.Precision); err == nil {
+			percent := numberValue.Percent()
+			if prec, perr := strconv.Atoi(precision); perr == nil {
+				percent = f.Rounding.round(percent, 'f', prec)
+			}
+			formattedValue, err = formatWithPrecision("f", precision, percent)
+			formattedValue += "%"
+		}
+	case 'b', 'd', 'i', 'u', 'o', 'x', 'X':
 		formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, numberValue)
 	case 'j':
-		formattedValue, err = formatJSON(value, ph.Width)
+		formattedValue, err = f.formatJSON(value, ph.Width)
 		if err == nil {
 			return formattedValue, nil // bail out early. we do not want signs or padding on JSON
 		}
+	case 'p':
+		formattedValue, err = formatPointer(value)
+	case 'q':
+		formattedValue, err = formatQuoted(value, ph.Precision)
 	case 's':
-		formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, value)
+		if ph.Layout != "" {
+			var t string
+			if t, err = formatTimeLayout(value, ph.Layout); err != nil {
+				break
+			}
+			value = t
+		} else if label, ok := f.enumLabel(value); ok {
+			value = label
+		} else if addr, ok := netAddress(value); ok {
+			value = addr
+		} else if text, ok, terr := textValue(value); ok {
+			if terr != nil {
+				err = terr
+				break
+			}
+			value = text
+		} else if str, ok, serr := stringerValue(value); ok {
+			if serr != nil {
+				err = serr
+				break
+			}
+			value = str
+		}
+		if str, ok := value.(string); ok && f.CollapseWhitespace {
+			value = collapseWhitespace(str)
+		}
+		if formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, value); err == nil && ph.Case != "" {
+			formattedValue = f.convertCase(formattedValue, ph.Case)
+		}
 	case 't':
-		formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, coerceBoolean(value))
+		b := coerceBoolean(value)
+		if str, ok := value.(string); ok && f.parseBoolStrings {
+			if parsed, err := strconv.ParseBool(str); err == nil {
+				b = parsed
+			}
+		}
+		formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, b)
 	case 'T':
 		formattedValue = typeName(value)
 	case 'v':
+		if ph.Layout != "" {
+			formattedValue, err = formatTimeLayout(value, ph.Layout)
+			break
+		}
+		if ph.Alternate != "" {
+			formattedValue = fmt.Sprintf("%#v", value) // Go syntax
+			break
+		}
+		if c, ok := complexValue(value); ok {
+			formattedValue = formatComplex(c, ph.Precision)
+			break
+		}
+		if e, ok := value.(error); ok && f.ErrorChain {
+			value = f.errorChain(e)
+		} else if f.ElementFormat != "" && isList(value) {
+			if value, err = f.formatElements(value); err != nil {
+				return "", err
+			}
+		} else if text, ok, terr := textValue(value); ok {
+			if terr != nil {
+				err = terr
+				break
+			}
+			value = text
+		}
 		formattedValue, err = formatWithPrecision(ph.Type, ph.Precision, value)
+	case 'C':
+		if formattedValue, err = formatWithPrecision("v", ph.Precision, value); err == nil {
+			formattedValue = f.formatCell(formattedValue, ph.Width, ph.Pad, f.align(ph))
+		}
+	case 'H':
+		formattedValue, err = f.formatHash(value, ph.Precision)
+	case 'R':
+		formattedValue, err = f.formatRelativeTime(value)
+	case 'U':
+		formattedValue, err = f.formatUUID(value)
 	default:
 		formattedValue = fmt.Sprint(value)
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("[sprintf] failed to format value %v as %q: %v", value, ph.Placeholder, err)
+		return "", wrapf(kindOf(err, ErrTypeMismatch), err, "[sprintf] failed to format value %v as %q: %v", value, ph.Placeholder, err)
 	}
 
 	signChar := ""
-	if reNumber.MatchString(ph.Type) {
-		if positive := numberValue.IsPositive(); !positive || ph.Sign != "" {
+	if isNumberType(ph.Type) {
+		positive := numberValue.IsPositive()
+		if f.NoNegativeZero && isFloatType(ph.Type) && isZeroNumber(formattedValue) {
+			positive = true // e.g. -0.01 rounded to 0.0, or math.Copysign(0, -1)
+			formattedValue = trimSign(formattedValue)
+		}
+		if !positive || ph.Sign != "" {
 			signChar = sign(positive)
-			formattedValue = reSign.ReplaceAllString(formattedValue, "") // remove sign
+			if positive && ph.Sign == " " {
+				signChar = " "
+			}
+			formattedValue = trimSign(formattedValue)
 		}
 	}
 
-	return alignedPad(formattedValue, ph.Width, ph.Pad, ph.Align, signChar), nil
+	if isDecimalType(ph.Type) {
+		zeroPad := 0
+		if strings.TrimPrefix(ph.Pad, "'") == "0" && f.align(ph) == AlignRight {
+			zeroPad = ph.Width - len(signChar)
+		}
+		if formattedValue, err = f.localizeNumber(formattedValue, ph.Grouping != "", zeroPad); err != nil {
+			return "", err
+		}
+	}
+
+	prefix := ""
+	if ph.Alternate != "" {
+		prefix = f.alternatePrefix(ph.Type, formattedValue)
+		if prefix != "" && strings.HasPrefix(formattedValue, "-") { // e.g. %b of a negative integer
+			signChar, formattedValue = "-", formattedValue[1:]
+		}
+	}
+
+	return f.alignedPad(formattedValue, ph.Width, f.padChar(ph), f.align(ph), signChar, prefix), nil
+}
+
+// formatPointer formats the address of pointers, slices, maps, channels and functions like Go's %p, e.g. 0xc000012345.
+func formatPointer(value interface{}) (string, error) {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Sprintf("%p", value), nil
+	}
+	return "", fmt.Errorf("expecting pointer but found %T", value)
+}
+
+// formatQuoted formats strings as double-quoted Go string literals and integers as single-quoted Go character literals.
+// The precision truncates strings before quoting.
+func formatQuoted(value interface{}, precision string) (string, error) {
+	switch value.(type) {
+	case string, []byte, fmt.Stringer, error:
+		return formatWithPrecision("q", precision, value)
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		char, err := formatChar(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.QuoteRune([]rune(char)[0]), nil
+	}
+	return "", fmt.Errorf("expecting string or integer but found %T", value)
+}
+
+// formatChar formats an integer as the character with that code point and a string as its first character.
+func formatChar(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		if s == "" {
+			return "", errors.New("empty string has no character")
+		}
+		r, _ := utf8.DecodeRuneInString(s)
+		return string(r), nil
+	}
+
+	i64, err := NewNumber(value).Int64()
+	if err != nil {
+		return "", err
+	}
+	if i64 < 0 || i64 > unicode.MaxRune {
+		return "", fmt.Errorf("%d is not a code point", i64)
+	}
+	return string(rune(i64)), nil
+}
+
+// alternatePrefix returns the prefix of the alternate form (`#` flag) of a formatted value.
+func (f *Formatter) alternatePrefix(typ string, formatted string) string {
+	switch typ {
+	case "o":
+		if f.OctalPrefix0o {
+			return "0o"
+		}
+		if formatted != "0" {
+			return "0"
+		}
+	case "x":
+		return "0x"
+	case "X":
+		return "0X"
+	case "b":
+		return "0b"
+	}
+	return ""
 }
 
 func formatWithPrecision(typ, precision string, value interface{}) (string, error) {
@@ -301,7 +705,7 @@ func formatWithPrecision(typ, precision string, value interface{}) (string, erro
 		// go does not support precision for "%t"
 		width, err := strconv.Atoi(precision)
 		if err != nil {
-			return "", fmt.Errorf("[sprintf] failed to parse precision %q: %v", precision, err)
+			return "", wrapf(ErrInvalidPlaceholder, err, "[sprintf] failed to parse precision %q: %v", precision, err)
 		}
 		return trim(fmt.Sprint(value), width), nil
 	}
@@ -309,18 +713,28 @@ func formatWithPrecision(typ, precision string, value interface{}) (string, erro
 	return fmt.Sprintf("%."+precision+typ, value), nil
 }
 
-func formatJSON(value interface{}, indent int) (string, error) {
-	var js []byte
-	var err error
-	if indent > 0 {
-		js, err = json.MarshalIndent(value, "", strings.Repeat(" ", indent))
-	} else {
-		js, err = json.Marshal(value)
+// formatJSON encodes a value for %j like `encodeJSON` does to a writer.
+func (f *Formatter) formatJSON(value interface{}, width int) (string, error) {
+	js := strings.Builder{}
+	if err := f.writeJSON(&js, value, width); err != nil {
+		return "", err
 	}
-	return string(js), err
+	return js.String(), nil
 }
 
-func alignedPad(value string, width int, padChar string, align string, sign string) string {
+// alignedPad pads a value to `width` runes (Unicode code points).
+// The pad character may be any rune, e.g. "'•".
+// Unlike JavaScript, which counts UTF-16 code units, characters outside the Basic Multilingual Plane, e.g. emoji, count once.
+func alignedPad(value string, width int, padChar string, align string, sign string, prefix string) string {
+	return alignedPadFunc(value, width, padChar, align, sign, prefix, utf8.RuneCountInString)
+}
+
+// alignedPadFunc pads a value to `width` as measured by `length`. A negative width left-aligns like C printf.
+// The sign and the prefix of the alternate form, e.g. "0x", precede the value; zero padding goes between them and the value.
+func alignedPadFunc(value string, width int, padChar string, align string, sign string, prefix string, length func(string) int) string {
+	if width < 0 {
+		width, align = -width, AlignLeft
+	}
 
 	if padChar == "" {
 		padChar = " "
@@ -328,29 +742,50 @@ func alignedPad(value string, width int, padChar string, align string, sign stri
 		padChar = padChar[1:]
 	}
 
-	padLen := width - len(sign) - len(value)
+	padLen := width - length(sign) - length(prefix) - length(value)
+	if padLen > 0 {
+		padLen /= length(padChar) // e.g. wide East Asian pad characters fill two columns
+	}
 
 	pad := ""
 	if width > 0 && padLen > 0 {
 		pad = strings.Repeat(padChar, padLen)
 	}
 
-	if align == "-" {
-		return sign + value + pad // e.g. "-3     "
+	if align == AlignLeft {
+		return sign + prefix + value + pad // e.g. "-0x3   "
+	}
+
+	if align == AlignCenter && padLen > 0 {
+		left := strings.Repeat(padChar, padLen/2)
+		right := strings.Repeat(padChar, padLen-padLen/2)
+		return left + sign + prefix + value + right // e.g. "  -3   "
 	}
 
 	if padChar == "0" {
-		return sign + pad + value // e.g. "-000003"
+		return sign + prefix + pad + value // e.g. "-0x00003"
 	}
 
-	return pad + sign + value // e.g. "     -3"
+	return pad + sign + prefix + value // e.g. "   -0x3"
+}
+
+// collapseWhitespace replaces runs of whitespace by a single space and trims both ends.
+func collapseWhitespace(value string) string {
+	return strings.TrimSpace(reWhitespace.ReplaceAllString(value, " "))
 }
 
+// trim truncates a value to at most `width` runes.
 func trim(value string, width int) string {
 	if width < 0 || width >= len(value) {
 		return value
 	}
-	return value[:width]
+	for i := range value {
+		if width == 0 {
+			return value[:i]
+		}
+		width--
+	}
+	return value
 }
 
 func typeName(v interface{}) string {
@@ -358,6 +793,19 @@ func typeName(v interface{}) string {
 		return "null"
 	}
 
+	switch v.(type) {
+	case json.Number:
+		return "number"
+	case complex64, complex128:
+		return "complex"
+	case net.IP:
+		return "ipaddr"
+	case net.IPNet, *net.IPNet:
+		return "ipnet"
+	case time.Duration:
+		return "duration"
+	}
+
 	tv := reflect.TypeOf(v)
 	switch tv.Kind() {
 	case reflect.Slice, reflect.Array:
@@ -382,6 +830,151 @@ func typeName(v interface{}) string {
 	return "object"
 }
 
+// netAddress returns the canonical string form of IP addresses and networks, e.g. "192.0.2.0/24".
+func netAddress(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case net.IP:
+		if v != nil {
+			return v.String(), true
+		}
+	case net.IPNet:
+		return v.String(), true
+	case *net.IPNet:
+		if v != nil {
+			return v.String(), true
+		}
+	}
+	return "", false
+}
+
+// textValue returns the result of the MarshalText method of an `encoding.TextMarshaler`, e.g. a time.Time.
+// Errors and `fmt.Formatter`s, which fmt prefers, and nil pointers are left to fmt.
+func textValue(v interface{}) (string, bool, error) {
+	marshaler, ok := v.(encoding.TextMarshaler)
+	if !ok {
+		return "", false, nil
+	}
+	switch v.(type) {
+	case error, fmt.Formatter:
+		return "", false, nil
+	}
+	if vv := reflect.ValueOf(v); vv.Kind() == reflect.Ptr && vv.IsNil() {
+		return "", false, nil
+	}
+	text, err := marshaler.MarshalText()
+	return string(text), true, err
+}
+
+// stringerValue returns the result of the String method of a `fmt.Stringer`.
+// Errors and `fmt.Formatter`s, which fmt prefers over String, and nil pointers are left to fmt.
+// A panicking String method is an error.
+func stringerValue(v interface{}) (str string, ok bool, err error) {
+	stringer, ok := v.(fmt.Stringer)
+	if !ok {
+		return "", false, nil
+	}
+	switch v.(type) {
+	case error, fmt.Formatter:
+		return "", false, nil
+	}
+	if vv := reflect.ValueOf(v); vv.Kind() == reflect.Ptr && vv.IsNil() {
+		return "", false, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("String method panicked: %v", r)
+		}
+	}()
+	return stringer.String(), true, nil
+}
+
+// invalidPlaceholder returns the beginning of a format string starting with a placeholder that cannot be parsed:
+// up to the first letter, which is presumably meant as type, e.g. "%y" of "%y years", but at most 16 bytes.
+// It is cut at a rune boundary, e.g. "%é" rather than "%\xc3".
+func invalidPlaceholder(format string) string {
+	end := 16
+	if i := strings.IndexFunc(format[1:], unicode.IsLetter); i >= 0 {
+		_, size := utf8.DecodeRuneInString(format[1+i:])
+		if 1+i+size <= end {
+			end = 1 + i + size
+		}
+	}
+	if end >= len(format) {
+		return format
+	}
+	for end > 0 && !utf8.RuneStart(format[end]) {
+		end--
+	}
+	return format[:end]
+}
+
+// isEmpty returns true for nil, nil pointers, empty strings and zero times.
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return v == ""
+	case time.Time:
+		return v.IsZero()
+	}
+	return isNull(v)
+}
+
+// isNull returns true for untyped nil, nil pointers and `driver.Valuer`s without a value, e.g. an invalid `sql.NullString`.
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if vv := reflect.ValueOf(v); vv.Kind() == reflect.Ptr && vv.IsNil() {
+		return true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	return false
+}
+
+// isConstant reports whether a format string has no placeholders and formats as it is.
+func isConstant(format string) bool {
+	return strings.IndexByte(format, '%') < 0
+}
+
+// trimSign removes the leading sign of a formatted number, e.g. "-1.5" becomes "1.5".
+func trimSign(formatted string) string {
+	if strings.HasPrefix(formatted, "-") || strings.HasPrefix(formatted, "+") {
+		return formatted[1:]
+	}
+	return formatted
+}
+
+// isFloatType reports whether a verb formats floating point numbers in decimal, e.g. %f.
+func isFloatType(typ string) bool {
+	switch typ {
+	case "e", "f", "g", "E", "G", "P":
+		return true
+	}
+	return false
+}
+
+// isZeroNumber reports whether a formatted number is zero, e.g. "-0.00" or "-0e+00".
+func isZeroNumber(formatted string) bool {
+	if i := strings.IndexAny(formatted, "eE"); i >= 0 {
+		formatted = formatted[:i]
+	}
+	return strings.Trim(formatted, "+-0.") == ""
+}
+
+// signFlag returns the sign flag of a placeholder: "+" if any, else " " for the space flag.
+func signFlag(flags string) string {
+	if strings.Contains(flags, "+") {
+		return "+"
+	}
+	if flags != "" {
+		return " "
+	}
+	return ""
+}
+
 func sign(positive bool) string {
 	if positive {
 		return "+"
@@ -389,10 +982,54 @@ func sign(positive bool) string {
 	return "-"
 }
 
+// dereference follows pointers to the value they point to.
+// Pointers implementing `fmt.Stringer` are kept as they are.
+func dereference(v interface{}) (value interface{}, isNil bool) {
+	for {
+		if _, ok := v.(fmt.Stringer); ok {
+			return v, false
+		}
+		vv := reflect.ValueOf(v)
+		if vv.Kind() != reflect.Ptr {
+			return v, false
+		}
+		if vv.IsNil() {
+			return nil, true
+		}
+		v = vv.Elem().Interface()
+	}
+}
+
+// isList returns true for slices and arrays except byte slices.
+func isList(v interface{}) bool {
+	if _, ok := v.([]byte); ok {
+		return false
+	}
+	kind := reflect.ValueOf(v).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
 func isFunc(v interface{}) bool {
 	return reflect.ValueOf(v).Kind() == reflect.Func
 }
 
+// callFunc calls a function without arguments and returns its first result.
+func callFunc(v interface{}) (interface{}, error) {
+	fv := reflect.ValueOf(v)
+	if fv.IsNil() {
+		return nil, errorf(ErrTypeMismatch, "[sprintf] cannot call nil function")
+	}
+	ft := fv.Type()
+	if ft.NumIn() > 1 || (ft.NumIn() == 1 && !ft.IsVariadic()) {
+		return nil, errorf(ErrTypeMismatch, "[sprintf] cannot call function %T which requires arguments", v)
+	}
+	if ft.NumOut() == 0 {
+		return nil, errorf(ErrTypeMismatch, "[sprintf] cannot use function %T which returns no value", v)
+	}
+	return fv.Call(nil)[0].Interface(), nil
+}
+
+// coerceBoolean converts a value to a boolean like JavaScript: zeros, including -0 and NaN, and empty strings are false.
 func coerceBoolean(v interface{}) bool {
 	vv := reflect.ValueOf(v)
 	if vv.Kind() == reflect.Ptr {
@@ -404,6 +1041,8 @@ func coerceBoolean(v interface{}) bool {
 		return v != 0
 	case int8:
 		return v != 0
+	case int16:
+		return v != 0
 	case int32:
 		return v != 0
 	case int64:
@@ -412,10 +1051,20 @@ func coerceBoolean(v interface{}) bool {
 		return v != 0
 	case uint8:
 		return v != 0
+	case uint16:
+		return v != 0
 	case uint32:
 		return v != 0
 	case uint64:
 		return v != 0
+	case float32:
+		return v != 0 && !math.IsNaN(float64(v))
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case complex64:
+		return v != 0 && !cmplx.IsNaN(complex128(v))
+	case complex128:
+		return v != 0 && !cmplx.IsNaN(v)
 	case bool:
 		return v
 	}
@@ -1,11 +1,17 @@
 package sprintfjs_test
 
 import (
+	"errors"
 	"fmt"
+	"go/parser"
+	"math"
+	"net"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
-	"brainloop/pe/util/sprintfjs"
+	"github.com/crazytyper/go-sprintfjs"
 )
 
 func TestFormat(t *testing.T) {
@@ -34,15 +40,19 @@ func TestFormat(t *testing.T) {
 		tc(`["foo","bar"]`,`%j`, []string{"foo", "bar"}),
 
 		tc(`2e+0`,`%e`, 2),
+		tc(`2E+0`,`%E`, 2),
 		tc(`2`,`%u`, 2),
 		tc(`4294967294`,`%u`, -2),
 
 		tc(`2.2`,`%f`, 2.2),
 		tc(`3.141592653589793`,`%g`, pi),
+		tc(`3.141592653589793`,`%G`, pi),
+		tc(`1E+21`,`%G`, 1e21),
 
 		tc(`10`,`%o`, 8),
 	 	tc(`37777777770`,`%o`, -8),
 		tc(`%s`,`%s`, "%s"),
+		tc(`bytes`,`%s`, []byte("bytes")),
 
 		tc(`ff`,`%x`, 255),
 	 	tc(`ffffff01`,`%x`, -255),
@@ -50,6 +60,7 @@ func TestFormat(t *testing.T) {
 	 	tc(`FFFFFF01`,`%X`, -255),
 
 		tc(`Polly wants a cracker`,`%2$s %3$s a %1$s`, "cracker", "Polly", "wants"),
+		tc(`j l a`,`%10$s %12$s %1$s`, "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"),
 		tc(`Hello world!`,`Hello %(who)s!`, map[string]interface{}{"who": "world"}),
 
 		tc(`true`,`%t`, true),
@@ -60,6 +71,20 @@ func TestFormat(t *testing.T) {
 		tc(`f`,`%.1t`, false),
 		tc(`false`,`%t`, ""),
 		tc(`false`,`%t`, 0),
+		tc(`false`,`%t`, 0.0),
+		tc(`false`,`%t`, math.Copysign(0, -1)),
+		tc(`false`,`%t`, math.NaN()),
+		tc(`false`,`%t`, float32(0)),
+		tc(`false`,`%t`, int16(0)),
+		tc(`false`,`%t`, uint16(0)),
+		tc(`false`,`%t`, complex(0, 0)),
+		tc(`false`,`%t`, complex(math.NaN(), 1)),
+		tc(`true`,`%t`, 0.5),
+		tc(`true`,`%t`, -0.001),
+		tc(`true`,`%t`, math.Inf(-1)),
+		tc(`true`,`%t`, float32(1e-10)),
+		tc(`true`,`%t`, int16(-1)),
+		tc(`true`,`%t`, complex(0, 1)),
 
 		tc(`null`,`%T`, nil),
 		tc(`boolean`,`%T`, true),
@@ -95,16 +120,62 @@ func TestFormat(t *testing.T) {
 		tc(`-0.0`,`%+.1f`, -0.01),
 		tc(`3.14159`,`%.6g`, pi),
 		tc(`3.14`,`%.3g`, pi),
+		tc(`3.14`,`%.3G`, pi),
+		tc(`+3.14E+0`,`%+.2E`, pi),
+		tc(`-3.14E-05`,`%.2E`, -pi/100000),
+		tc(`-1.2E-05`,`%.2G`, -0.0000123),
 		tc(`3`,`%.1g`, pi),
 		tc(`-000000123`,`%+010d`, -123),
 		tc(`______-123`,"%+'_10d", -123),
+		tc(` 2`,`% d`, 2),
+		tc(`-2`,`% d`, -2),
+		tc(` 0`,`% d`, 0),
+		tc(` 2.50`,`% .2f`, 2.5),
+		tc(`+2`,`% +d`, 2),
+		tc(`+2`,`%+ d`, 2),
+		tc(` 0042`,`% 05d`, 42),
+		tc(`-0042`,`% 05d`, -42),
+		tc(`   2`,`% 4d`, 2),
+		tc(` 2 `,`% -3d`, 2),
+		tc(`abc`,`% s`, "abc"),
 		tc(`-234.34 123.2`,`%f %f`, -234.34, 123.2),
 
+		// alternate form
+		tc(`010`,`%#o`, 8),
+		tc(`0`,`%#o`, 0),
+		tc(`  010`,`%#5o`, 8),
+		tc(`010  `,`%#-5o`, 8),
+		tc(`00010`,`%#05o`, 8),
+		tc(`0xff`,`%#x`, 255),
+		tc(`0XFF`,`%#X`, 255),
+		tc(`0x0`,`%#x`, 0),
+		tc(`0b101`,`%#b`, 5),
+		tc(`0x0000ff`,`%#08x`, 255),
+		tc(`0b00000101`,`%#010b`, 5),
+		tc(`    0xff`,`%#8x`, 255),
+		tc(`0xff    `,`%#-8x`, 255),
+		tc(`0xfffffffe`,`%#x`, -2),
+		tc(`0x000000ff`,`%#010x`, 255),
+		tc(`0X000000FF`,`%#010X`, 255),
+		tc(`0000000010`,`%#010o`, 8),
+		tc(`-0b101`,`%#b`, -5),
+		tc(`-0b00101`,`%#08b`, -5),
+		tc(`  -0b101`,`%#8b`, -5),
+		tc(`-0b101  `,`%#-8b`, -5),
+		tc(`0x00fffffffe`,`%#012x`, -2),
+		tc(`__0xff__`,`%#'_=8x`, 255),
+
 		// padding
 		tc(`-0002`,`%05d`, -2),
 		tc(`-0002`,`%05i`, -2),
 		tc(`    <`,`%5s`, "<"),
 		tc(`0000<`,`%05s`, "<"),
+		tc(` true`,`%5t`, true),
+		tc(`false`,`%5t`, false),
+		tc(`true `,`%-5t`, true),
+		tc(`    t`,`%5.1t`, true),
+		tc(`f    `,`%-5.1t`, false),
+		tc(`__true`,"%'_6t", true),
 		tc(`____<`,"%'_5s", "<"),
 		tc(`>    `,`%-5s`, ">"),
 		tc(`>0000`,`%0-5s`, ">"),
@@ -157,3 +228,404 @@ func TestFormatAST(t *testing.T) {
 		t.Fatalf("Expected %q has %q", expected, actual)
 	}
 }
+
+func TestFormatConstant(t *testing.T) {
+	for _, format := range []string{"", "Hello world!", "ünïcödé"} {
+		ast, err := sprintfjs.Parse(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == "" && len(ast) != 0 || format != "" && (len(ast) != 1 || ast[0].Text != format) {
+			t.Errorf("expected %q to parse to a single text node, had %#v", format, ast)
+		}
+
+		actual, err := sprintfjs.Format(format, "unused")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != format {
+			t.Errorf("expected %q had %q", format, actual)
+		}
+	}
+
+	formatter := &sprintfjs.Formatter{MaxOutputBytes: 5}
+	if _, err := formatter.Format("Hello world!"); !errors.Is(err, sprintfjs.ErrOutputLimit) {
+		t.Errorf("expected the output limit to apply to constant strings, had %v", err)
+	}
+}
+
+func TestFormatPositionalErrors(t *testing.T) {
+	args := []interface{}{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	for _, format := range []string{`%0$s`, `%00$s`} {
+		if _, err := sprintfjs.Parse(format); err == nil {
+			t.Errorf("expected %q to fail", format)
+		}
+	}
+
+	if _, err := sprintfjs.Format(`%11$s`, args...); err == nil {
+		t.Errorf("expected %q to fail", `%11$s`)
+	}
+
+	ast, err := sprintfjs.Parse(`%12$s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast[0].ParamNo != 12 {
+		t.Fatalf("expected parameter 12 had %d", ast[0].ParamNo)
+	}
+}
+
+type point struct {
+	X, Y int
+	Tags []string
+}
+
+func TestFormatGoSyntax(t *testing.T) {
+	values := []interface{}{
+		map[string]int{"a": 1, "b": 2},
+		[]interface{}{1, "two", 3.5, nil, true},
+		[][]int{{1, 2}, {3}},
+		map[string][]point{"points": {{X: 1, Y: 2, Tags: []string{"a"}}, {X: 3}}},
+		&point{X: 1},
+		struct{ Name string }{"x"},
+	}
+
+	expected := []string{
+		`map[string]int{"a":1, "b":2}`,
+		`[]interface {}{1, "two", 3.5, interface {}(nil), true}`,
+		`[][]int{[]int{1, 2}, []int{3}}`,
+		`map[string][]sprintfjs_test.point{"points":[]sprintfjs_test.point{sprintfjs_test.point{X:1, Y:2, Tags:[]string{"a"}}, sprintfjs_test.point{X:3, Y:0, Tags:[]string(nil)}}}`,
+		`&sprintfjs_test.point{X:1, Y:0, Tags:[]string(nil)}`,
+		`struct { Name string }{Name:"x"}`,
+	}
+
+	for i, value := range values {
+		actual, err := sprintfjs.Format(`%#v`, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected[i] != actual {
+			t.Errorf("expected %q had %q", expected[i], actual)
+		}
+		if _, err := parser.ParseExpr(actual); err != nil {
+			t.Errorf("expected %q to be a Go expression: %v", actual, err)
+		}
+	}
+}
+
+func TestFormatOmit(t *testing.T) {
+	var nilString *string
+
+	type testcase struct {
+		Expected string
+		Format   string
+		Args     []interface{}
+	}
+	testcases := []testcase{
+		{`a (b)`, `%s (%s?)`, []interface{}{"a", "b"}},
+		{`a`, `%s (%s?)`, []interface{}{"a", nil}},
+		{`a`, `%s (%s?)`, []interface{}{"a", ""}},
+		{`a`, `%s (%s?)`, []interface{}{"a", nilString}},
+		{`a (0)`, `%s (%d?)`, []interface{}{"a", 0}},
+		{`ac`, `%s, %s?, %s`, []interface{}{"a", nil, "c"}}, // text on both sides is omitted
+		{`c`, `[%s?] %s`, []interface{}{nil, "c"}},
+		{`Hello world (admin)!`, `Hello %(name)s (%(role)s?)!`, []interface{}{map[string]interface{}{"name": "world", "role": "admin"}}},
+		{`Hello world`, `Hello %(name)s (%(role)s?)!`, []interface{}{map[string]interface{}{"name": "world"}}},
+	}
+	for _, tc := range testcases {
+		actual, err := sprintfjs.Format(tc.Format, tc.Args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.Expected != actual {
+			t.Errorf("expected %q had %q", tc.Expected, actual)
+		}
+	}
+}
+
+func TestFormatStrings(t *testing.T) {
+	actual, err := sprintfjs.FormatStrings(
+		`%s: %d items at %.2f, %x, in stock %t, discounted %t, %5.1t`,
+		"apples", "42", "0.5", "255", "true", "false", "F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `apples: 42 items at 0.50, ff, in stock true, discounted false,     f`
+	if expected != actual {
+		t.Fatalf("expected %q had %q", expected, actual)
+	}
+
+	if actual, _ := sprintfjs.Format(`%t`, "false"); actual != "true" {
+		t.Fatalf("expected Format to keep JavaScript semantics, had %q", actual)
+	}
+
+	if _, err := sprintfjs.FormatStrings(`%d`, "forty-two"); err == nil {
+		t.Fatal("expected a non-numeric string to fail")
+	}
+}
+
+func TestMustFormat(t *testing.T) {
+	if actual := sprintfjs.MustFormat(`%s has %d`, "alice", 3); actual != "alice has 3" {
+		t.Fatalf("unexpected %q", actual)
+	}
+	if ast := sprintfjs.MustParse(`%s has %d`); len(ast) != 3 {
+		t.Fatalf("unexpected %v", ast)
+	}
+
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected %s to panic", name)
+			}
+		}()
+		f()
+	}
+	mustPanic("MustFormat", func() { sprintfjs.MustFormat(`%d`, "x") })
+	mustPanic("MustParse", func() { sprintfjs.MustParse(`%y`) })
+}
+
+func TestFormatDynamicWidth(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`   42`, `%*d`, 5, 42),
+		ftc(`42   |`, `%*d|`, -5, 42),
+		ftc(`00042`, `%0*d`, 5, 42),
+		ftc(`  a|b  `, `%*s|%-*s`, 3, "a", 3, "b"),
+		ftc(`   42 x`, `%3$*d %s`, 5, "x", 42),
+		ftc(`  Berlin`, `%(city)*s`, 8, map[string]interface{}{"city": "Berlin"}),
+		ftc(`42`, `%*d`, uint8(0), 42),
+	})
+
+	for _, args := range [][]interface{}{{"5", 42}, {5.0, 42}, {}, {5}} {
+		if _, err := sprintfjs.Format(`%*d`, args...); err == nil {
+			t.Errorf("expected %v to fail", args)
+		}
+	}
+	if _, err := sprintfjs.Format(`%*d`, sprintfjs.DefaultMaxWidth+1, 42); err == nil {
+		t.Error("expected a width exceeding the maximum to fail")
+	}
+}
+
+func TestFormatDynamicPrecision(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`3.14`, `%.*f`, 2, 3.14159),
+		ftc(`  3.14`, `%*.*f`, 6, 2, 3.14159),
+		ftc(`3.14  |`, `%*.*f|`, -6, 2, 3.14159),
+		ftc(`Hel`, `%.*s`, 3, "Hello"),
+		ftc(`3.14159`, `%.*f`, -1, 3.14159),
+		ftc(`3.1 x`, `%3$.*f %s`, 1, "x", 3.14159),
+	})
+
+	for _, args := range [][]interface{}{{"2", 3.14}, {2}, {sprintfjs.DefaultMaxWidth + 1, 3.14}} {
+		if _, err := sprintfjs.Format(`%.*f`, args...); err == nil {
+			t.Errorf("expected %v to fail", args)
+		}
+	}
+}
+
+func TestFormatPrecisionTruncatesRunes(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`hél`, `%.3s`, "héllo"),
+		ftc(`日本`, `%.2s`, "日本語"),
+		ftc(`日本語`, `%.5s`, "日本語"),
+		ftc("e\u0301", `%.2s`, "e\u0301te\u0301"), // combining characters count as runes of their own
+		ftc(`tr`, `%.2t`, true),
+		ftc(`hél`, `%.3v`, "héllo"),
+		ftc(`日本`, `%-.2C`, "日本語"),
+		ftc(``, `%.0s`, "héllo"),
+	})
+}
+
+func TestFormatPadsRunes(t *testing.T) {
+	rows := [][]interface{}{{"Zoë", 3}, {"Bob", 12}, {"Ångström", 7}, {"日本", 1}}
+	expected := []string{
+		"|Zoë       |  3|",
+		"|Bob       | 12|",
+		"|Ångström  |  7|",
+		"|日本        |  1|",
+	}
+	for i, row := range rows {
+		actual, err := sprintfjs.Format(`|%-10s|%3d|`, row...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected[i] {
+			t.Errorf("expected %q had %q", expected[i], actual)
+		}
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`  héllo`, `%7s`, "héllo"),
+		ftc(`ééhéllo`, `%'é7s`, "héllo"),
+		ftc(`    😀`, `%5s`, "😀"), // a single rune, unlike two UTF-16 code units in JavaScript
+	})
+}
+
+func TestFormatChar(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`A`, `%c`, 65),
+		ftc(`A`, `%c`, "A"),
+		ftc(`é`, `%c`, 'é'),
+		ftc(`日`, `%c`, "日本"), // the first rune of multi-rune strings
+		ftc(`A`, `%c`, byte('A')),
+		ftc(`6`, `%c`, "65"),
+		ftc(`  A`, `%3c`, "A"),
+	})
+
+	for _, value := range []interface{}{"", -1, 0x110000, []int{65}} {
+		if _, err := sprintfjs.Format(`%c`, value); err == nil {
+			t.Errorf("expected %v to fail", value)
+		}
+	}
+}
+
+func TestFormatNegativeWidth(t *testing.T) {
+	ast := mustParse(t, `|%5d|%5s|%5C|`)
+	for i := range ast {
+		ast[i].Width = -ast[i].Width
+	}
+	actual, err := sprintfjs.FormatAST(ast, 42, "ab", "cd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `|42   |ab   |cd   |`; actual != expected {
+		t.Fatalf("expected %q had %q", expected, actual)
+	}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`42   |`, `%*d|`, -5, 42),
+		ftc(`42   |`, `%-*d|`, -5, 42),
+	})
+}
+
+func TestVformat(t *testing.T) {
+	args := []interface{}{"alice", 3}
+	actual, err := sprintfjs.Vformat(`%s has %d`, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := sprintfjs.Format(`%s has %d`, args...); actual != expected {
+		t.Fatalf("expected %q had %q", expected, actual)
+	}
+
+	if actual, err := (&sprintfjs.Formatter{}).Vformat(`%v`, []interface{}{args}); err != nil || actual != "[alice 3]" {
+		t.Fatalf("expected the slice as single argument had %q, %v", actual, err)
+	}
+}
+
+func TestFormatQuoted(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`"hello"`, `%q`, "hello"),
+		ftc(`"a\tb\n\"c\""`, `%q`, "a\tb\n\"c\""),
+		ftc(`"héllo"`, `%q`, "héllo"),
+		ftc(`'A'`, `%q`, 65),
+		ftc(`'é'`, `%q`, 'é'),
+		ftc(`"hé"`, `%.2q`, "héllo"),
+		ftc(`   "ab"`, `%7q`, "ab"),
+		ftc(`"ab"   |`, `%-7q|`, "ab"),
+		ftc(`"1.5"`, `%+q`, "1.5"),
+		ftc(`"Hello world!"`, `%q`, helloStringer{}),
+		ftc(`"Berlin"`, `%(city)q`, map[string]interface{}{"city": "Berlin"}),
+	})
+
+	for _, value := range []interface{}{true, 1.5, []int{1}} {
+		if _, err := sprintfjs.Format(`%q`, value); err == nil {
+			t.Errorf("expected %v to fail", value)
+		}
+	}
+}
+
+type helloStringer struct{}
+
+func (helloStringer) String() string { return "Hello world!" }
+
+func TestFormatPointer(t *testing.T) {
+	i := 42
+	s := []int{1}
+	m := map[string]int{}
+	c := make(chan int)
+	f := func() string { return "called" }
+
+	for _, value := range []interface{}{&i, s, m, c, f} {
+		expected := fmt.Sprintf("%p", value)
+		actual, err := sprintfjs.Format(`%p`, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %q had %q", expected, actual)
+		}
+		if padded, _ := sprintfjs.Format(`%-20p|`, value); padded != fmt.Sprintf("%-20s|", expected) {
+			t.Errorf("expected padded address had %q", padded)
+		}
+	}
+
+	if actual, _ := (&sprintfjs.Formatter{DereferencePointers: true}).Format(`%p`, &i); actual != fmt.Sprintf("%p", &i) {
+		t.Errorf("expected %%p not to dereference, had %q", actual)
+	}
+
+	for _, value := range []interface{}{42, "text", struct{}{}} {
+		if _, err := sprintfjs.Format(`%p`, value); err == nil {
+			t.Errorf("expected %v to fail", value)
+		}
+	}
+}
+
+type bothStringerAndError struct{}
+
+func (bothStringerAndError) String() string { return "string" }
+func (bothStringerAndError) Error() string  { return "error" }
+
+func TestFormatStringer(t *testing.T) {
+	var nilStringer *net.IPNet
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`Hello world!`, `%s`, helloStringer{}),
+		ftc(`Hello`, `%.5s`, helloStringer{}),
+		ftc(`  Hello world!`, `%14s`, helloStringer{}),
+		ftc(`Hello world!`, `%v`, helloStringer{}),
+		ftc(`Hello world!`, `%(greeter)s`, map[string]interface{}{"greeter": helloStringer{}}),
+		ftc(`error`, `%s`, bothStringerAndError{}),
+		ftc(`<nil>`, `%s`, nilStringer),
+		ftc(`%!v(PANIC=String method: boom)`, `%v`, panickingStringer{}),
+	})
+
+	_, err := sprintfjs.Format(`%s`, panickingStringer{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the panic to be an error, had %v", err)
+	}
+}
+
+type textMarshaler struct {
+	text string
+	err  error
+}
+
+func (m textMarshaler) MarshalText() ([]byte, error) { return []byte(m.text), m.err }
+func (m textMarshaler) String() string               { return "stringer" }
+
+func TestFormatTextMarshaler(t *testing.T) {
+	ip := net.ParseIP("192.0.2.1")
+	created := time.Date(2019, 5, 19, 14, 30, 0, 0, time.UTC)
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`192.0.2.1`, `%s`, ip),
+		ftc(`192.0.2.1`, `%v`, ip),
+		ftc(`   192.0.2.1`, `%12v`, ip),
+		ftc(`192.0`, `%.5v`, ip),
+		ftc(`2019-05-19T14:30:00Z`, `%s`, created),
+		ftc(`2019-05-19T14:30:00Z`, `%v`, created),
+		ftc(`text`, `%s`, textMarshaler{text: "text"}),
+		ftc(`text`, `%v`, &textMarshaler{text: "text"}),
+		ftc(`text`, `%(m)s`, map[string]interface{}{"m": textMarshaler{text: "text"}}),
+		ftc(`sprintfjs_test.textMarshaler{text:"text", err:error(nil)}`, `%#v`, textMarshaler{text: "text"}),
+	})
+
+	for _, format := range []string{`%s`, `%v`} {
+		_, err := sprintfjs.Format(format, textMarshaler{err: errors.New("cannot marshal")})
+		if err == nil || !strings.Contains(err.Error(), "cannot marshal") {
+			t.Errorf("expected %s to fail with the MarshalText error, had %v", format, err)
+		}
+	}
+}
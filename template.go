@@ -0,0 +1,33 @@
+package sprintfjs
+
+// Template is a parsed format string, ready to format arguments.
+type Template struct {
+	formatter *Formatter
+	ast       AST
+}
+
+// Compile parses a format string into a template.
+func Compile(format string) (*Template, error) {
+	return defaultFormatter.Compile(format)
+}
+
+// Compile parses a format string into a template formatting with this formatter.
+// Options changed after compiling apply to the formatting but not to the parsing, e.g. `MaxWidth`.
+func (f *Formatter) Compile(format string) (*Template, error) {
+	ast, err := f.Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{formatter: f, ast: ast}, nil
+}
+
+// Format formats the arguments according to the template. See `Format`.
+func (t *Template) Format(args ...interface{}) (string, error) {
+	return t.formatter.FormatAST(t.ast, args...)
+}
+
+// NumArgs returns the number of arguments the template consumes,
+// i.e. the minimum length of the arguments to `Format`.
+func (t *Template) NumArgs() int {
+	return t.ast.numArgs(t.formatter.ContextArg)
+}
@@ -0,0 +1,66 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+var greeting = func() *sprintfjs.Template {
+	tmpl, err := sprintfjs.Compile(`Hello %s, you have %d new %s`)
+	if err != nil {
+		panic(err)
+	}
+	return tmpl
+}()
+
+func TestTemplate(t *testing.T) {
+	actual, err := greeting.Format("alice", 3, "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Hello alice, you have 3 new messages"; expected != actual {
+		t.Fatalf("expected %q had %q", expected, actual)
+	}
+
+	if _, err := greeting.Format("alice"); err == nil {
+		t.Fatal("expected missing arguments to fail")
+	}
+
+	if _, err := sprintfjs.Compile(`%y`); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
+
+func TestTemplateNumArgs(t *testing.T) {
+	testcases := []struct {
+		format  string
+		numArgs int
+	}{
+		{`no placeholders %%`, 0},
+		{`%s %d %s`, 3},
+		{`%2$s %1$s %2$s`, 2},
+		{`%s %3$s`, 3},
+		{`%(user.name)s %(user.age)d`, 1},
+		{`%*d %s`, 3},
+		{`%3$*d`, 3},
+		{`%*.*f`, 3},
+	}
+	for _, tc := range testcases {
+		tmpl, err := sprintfjs.Compile(tc.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if numArgs := tmpl.NumArgs(); numArgs != tc.numArgs {
+			t.Errorf("expected %q to consume %d arguments had %d", tc.format, tc.numArgs, numArgs)
+		}
+	}
+
+	tmpl, err := (&sprintfjs.Formatter{ContextArg: 2}).Compile(`%(name)s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numArgs := tmpl.NumArgs(); numArgs != 2 {
+		t.Errorf("expected the context argument to be consumed had %d", numArgs)
+	}
+}
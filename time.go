@@ -0,0 +1,63 @@
+package sprintfjs
+
+import (
+	"fmt"
+	"time"
+)
+
+// relativeTimeLayout is used for times too far from now to be described in words.
+const relativeTimeLayout = "2006-01-02"
+
+func (f *Formatter) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
+	}
+	return f.Now()
+}
+
+// formatRelativeTime describes a time relative to now, e.g. "3 minutes ago" or "in 2 hours".
+// Times less than a minute away are "just now", times 30 days or more away are formatted as a date.
+func (f *Formatter) formatRelativeTime(value interface{}) (string, error) {
+	t, ok := value.(time.Time)
+	if !ok {
+		return "", fmt.Errorf("expecting time.Time but found %T", value)
+	}
+
+	d := f.now().Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now", nil
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	default:
+		return t.Format(relativeTimeLayout), nil
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit), nil
+	}
+	return fmt.Sprintf("%d %s ago", n, unit), nil
+}
+
+// formatTimeLayout formats a time.Time with a Go reference layout, e.g. "2006-01-02".
+func formatTimeLayout(value interface{}, layout string) (string, error) {
+	t, ok := value.(time.Time)
+	if !ok {
+		return "", fmt.Errorf("expecting time.Time but found %T", value)
+	}
+	return t.Format(layout), nil
+}
@@ -0,0 +1,86 @@
+package sprintfjs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2019, 5, 19, 12, 0, 0, 0, time.UTC)
+	formatter := &sprintfjs.Formatter{Now: func() time.Time { return now }}
+
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`just now`, `%R`, now),
+		ftc(`just now`, `%R`, now.Add(-59*time.Second)),
+		ftc(`just now`, `%R`, now.Add(30*time.Second)),
+		ftc(`1 minute ago`, `%R`, now.Add(-time.Minute)),
+		ftc(`3 minutes ago`, `%R`, now.Add(-3*time.Minute-10*time.Second)),
+		ftc(`in 2 hours`, `%R`, now.Add(2*time.Hour+time.Minute)),
+		ftc(`1 hour ago`, `%R`, now.Add(-time.Hour)),
+		ftc(`5 days ago`, `%R`, now.Add(-5*24*time.Hour)),
+		ftc(`in 1 day`, `%R`, now.Add(25*time.Hour)),
+		ftc(`2018-12-24`, `%R`, time.Date(2018, 12, 24, 18, 0, 0, 0, time.UTC)),
+		ftc(`2020-01-01`, `%R`, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		ftc(`  just now`, `%10R`, now),
+	})
+
+	if _, err := formatter.Format(`%R`, "yesterday"); err == nil {
+		t.Fatal("expected an error for a non-time value")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	d := 90 * time.Minute
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`1h30m0s`, `%s`, d),
+		ftc(`1h30m0s`, `%v`, d),
+		ftc(`   1h30m0s`, `%10s`, d),
+		ftc(`1h30`, `%.4s`, d),
+		ftc(`1.5s`, `%(elapsed)s`, map[string]interface{}{"elapsed": 1500 * time.Millisecond}),
+		ftc(`5400000000000`, `%d`, d),
+		ftc(`5,400,000,000,000`, `%,d`, d),
+		ftc(`5400000000000`, `%j`, d),
+		ftc(`duration`, `%T`, d),
+		ftc(`number`, `%T`, int64(d)),
+		ftc(`-1m0s`, `%s`, -time.Minute),
+	})
+}
+
+func TestFormatTimeLayout(t *testing.T) {
+	created := time.Date(2019, 5, 19, 14, 30, 0, 0, time.UTC)
+	data := map[string]interface{}{"created": created, "zero": time.Time{}, "name": "alice"}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`2019-05-19`, `%(created|2006-01-02)s`, data),
+		ftc(`14:30`, `%(created|15:04)v`, data),
+		ftc(`19 May 2019, 2:30 PM`, `%(created|2 Jan 2006, 3:04 PM)s`, data),
+		ftc(`  2019`, `%(created|2006)6s`, data),
+		ftc(`May`, `%(created|January).3s`, data),
+		ftc(`0001-01-01`, `%(zero|2006-01-02)s`, data),
+		ftc(`alice`, `%(name)s (%(zero|2006-01-02)s?)`, data),
+		ftc(`alice (2019-05-19)`, `%(name)s (%(created|2006-01-02)s?)`, data),
+	})
+
+	if _, err := sprintfjs.Format(`%(name|2006-01-02)s`, data); err == nil {
+		t.Error("expected a layout of a string to fail")
+	}
+	for _, format := range []string{`%(created|)s`, `%(created|2006)d`} {
+		if _, err := sprintfjs.Parse(format); err == nil {
+			t.Errorf("expected %q not to parse", format)
+		}
+	}
+
+	ast, err := sprintfjs.Parse(`%(created|2006-01-02)s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast[0].Layout != "2006-01-02" || len(ast[0].Keys) != 1 || ast[0].Keys[0] != "created" {
+		t.Errorf("unexpected node %+v", ast[0])
+	}
+	if other := sprintfjs.MustParse(`%(created|2006)s`); ast.Equal(other) || ast.Hash() == other.Hash() {
+		t.Error("expected placeholders with different layouts to differ")
+	}
+}
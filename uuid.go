@@ -0,0 +1,33 @@
+package sprintfjs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const uuidLen = 16
+
+// formatUUID renders a 16 byte array or slice in the canonical hyphenated UUID form.
+func (f *Formatter) formatUUID(value interface{}) (string, error) {
+	vv := reflect.ValueOf(value)
+	if (vv.Kind() != reflect.Array && vv.Kind() != reflect.Slice) || vv.Type().Elem().Kind() != reflect.Uint8 {
+		return "", fmt.Errorf("expecting %d bytes but found %T", uuidLen, value)
+	}
+	if vv.Len() != uuidLen {
+		return "", fmt.Errorf("expecting %d bytes but found %d", uuidLen, vv.Len())
+	}
+
+	b := make([]byte, uuidLen)
+	for i := range b {
+		b[i] = byte(vv.Index(i).Uint())
+	}
+
+	h := hex.EncodeToString(b)
+	uuid := h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	if f.UpperCaseUUIDs {
+		return strings.ToUpper(uuid), nil
+	}
+	return uuid, nil
+}
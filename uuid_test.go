@@ -0,0 +1,30 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+type uuid [16]byte
+
+func TestFormatUUID(t *testing.T) {
+	id := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`123e4567-e89b-12d3-a456-426614174000`, `%U`, id),
+		ftc(`123e4567-e89b-12d3-a456-426614174000`, `%U`, id[:]),
+		ftc(`123e4567-e89b-12d3-a456-426614174000`, `%U`, uuid(id)),
+		ftc(`00000000-0000-0000-0000-000000000000`, `%U`, [16]byte{}),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{UpperCaseUUIDs: true}, []formatterTestcase{
+		ftc(`123E4567-E89B-12D3-A456-426614174000`, `%U`, id),
+	})
+
+	for _, value := range []interface{}{id[:15], [17]byte{}, "123e4567-e89b-12d3-a456-426614174000", []int{1, 2}} {
+		if _, err := sprintfjs.Format(`%U`, value); err == nil {
+			t.Errorf("expected %T to fail", value)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sprintfjs
+
+// Validate checks that a format string only refers to allowed named keys, e.g. before accepting a template
+// supplied by users. It is meant for format strings formatted with a single context argument holding the keys.
+// A key is allowed if its path or the path of one of its parents is in `allowedKeys`,
+// e.g. "user" allows %(user.name)s and %(user.roles[0])s, whereas "user.name" allows only %(user.name)s.
+// Placeholders reading arguments rather than keys, e.g. %s, %2$s or a * width, are not allowed.
+// Failures are `*ParseError`s of the offending placeholder; errors.Is(err, ErrInvalidKey) holds for disallowed keys
+// and placeholders without keys, and errors.Is(err, ErrInvalidPlaceholder) for format strings that cannot be parsed.
+func Validate(format string, allowedKeys []string) error {
+	return defaultFormatter.Validate(format, allowedKeys)
+}
+
+// Validate checks that a format string only refers to allowed named keys. See `Validate`.
+func (f *Formatter) Validate(format string, allowedKeys []string) error {
+	ast, err := f.Parse(format)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(allowedKeys))
+	for _, key := range allowedKeys {
+		allowed[key] = true
+	}
+
+	for _, node := range ast {
+		if node.Placeholder == "" {
+			continue
+		}
+		var err *Error
+		switch {
+		case node.Keys == nil:
+			err = errorf(ErrInvalidKey, "[sprintf] placeholder %q reads an argument instead of a key", node.Placeholder)
+		case node.DynamicWidth || node.DynamicPrecision:
+			err = errorf(ErrInvalidKey, "[sprintf] placeholder %q reads its width or precision from an argument", node.Placeholder)
+		case !keyAllowed(node.Keys, allowed):
+			err = errorf(ErrInvalidKey, "[sprintf] key %q of %q is not allowed", keyPath(node.Keys), node.Placeholder)
+		}
+		if err != nil {
+			return &ParseError{Format: format, Offset: node.Offset, Text: node.Placeholder, Err: err.at(node.Placeholder, 0)}
+		}
+	}
+	return nil
+}
+
+// keyAllowed reports whether the path of `keys` or of one of its parents is allowed.
+func keyAllowed(keys []string, allowed map[string]bool) bool {
+	for i := len(keys); i > 0; i-- {
+		if allowed[keyPath(keys[:i])] {
+			return true
+		}
+	}
+	return false
+}
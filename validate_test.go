@@ -0,0 +1,58 @@
+package sprintfjs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestValidate(t *testing.T) {
+	allowed := []string{"user", "order.id", "items"}
+
+	for _, format := range []string{
+		`Hello %(user)s!`,
+		`Hello %(user.name)s, you are %(user.age)d`,
+		`Order %(order.id)05d: %(items[*].name)s, %(items[0].price).2f`,
+		`100%% plain text`,
+		``,
+	} {
+		if err := sprintfjs.Validate(format, allowed); err != nil {
+			t.Errorf("expected %q to be valid, had %v", format, err)
+		}
+	}
+
+	type testcase struct {
+		Format string
+		Kind   error
+		Offset int
+		Text   string
+	}
+	testcases := []testcase{
+		{`Hello %(usr.name)s`, sprintfjs.ErrInvalidKey, 6, `%(usr.name)s`},
+		{`%(order.id)s %(order.total)s`, sprintfjs.ErrInvalidKey, 13, `%(order.total)s`},
+		{`%(order)j`, sprintfjs.ErrInvalidKey, 0, `%(order)j`},
+		{`Hello %s`, sprintfjs.ErrInvalidKey, 6, `%s`},
+		{`Hello %(user)s and %2$s`, sprintfjs.ErrInvalidKey, 19, `%2$s`},
+		{`%(user)*s`, sprintfjs.ErrInvalidKey, 0, `%(user)*s`},
+		{`Hello %(user)y`, sprintfjs.ErrInvalidPlaceholder, 6, `%(u`},
+	}
+	for _, tc := range testcases {
+		err := sprintfjs.Validate(tc.Format, allowed)
+		if !errors.Is(err, tc.Kind) {
+			t.Errorf("expected %q to fail with %v, had %v", tc.Format, tc.Kind, err)
+			continue
+		}
+		var parseErr *sprintfjs.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Offset != tc.Offset || parseErr.Text != tc.Text {
+			t.Errorf("expected %q to fail at %d with %q, had %v", tc.Format, tc.Offset, tc.Text, err)
+		}
+	}
+
+	if err := sprintfjs.Validate(`%(user)s`, nil); !errors.Is(err, sprintfjs.ErrInvalidKey) {
+		t.Errorf("expected no keys to be allowed, had %v", err)
+	}
+	if err := (&sprintfjs.Formatter{AllowedVerbs: "s"}).Validate(`%(user)d`, allowed); !errors.Is(err, sprintfjs.ErrVerbNotAllowed) {
+		t.Errorf("expected the formatter options to apply, had %v", err)
+	}
+}
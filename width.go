@@ -0,0 +1,45 @@
+package sprintfjs
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// alignedPad pads a value like `alignedPad` measuring the width as configured by `EastAsianWidth`.
+// With a `padLimit` the width is reduced to what yields the same first `padLimit` bytes, which suffices where the output
+// is cut at `MaxOutputBytes` anyway, e.g. %1000000s is not padded with a million spaces.
+func (f *Formatter) alignedPad(value string, width int, padChar string, align string, sign string, prefix string) string {
+	if f.padLimit > 0 {
+		// the padding before the value, which center alignment halves, covers the limit in any alignment
+		if max := 2*f.padLimit + f.stringWidth(sign+prefix+value); width > max {
+			width = max
+		} else if width < -max {
+			width = -max
+		}
+	}
+	return alignedPadFunc(value, width, padChar, align, sign, prefix, f.stringWidth)
+}
+
+// stringWidth returns the width of a string in runes, or in terminal columns if `EastAsianWidth` is set.
+func (f *Formatter) stringWidth(s string) int {
+	if f.EastAsianWidth {
+		return displayWidth(s)
+	}
+	return utf8.RuneCountInString(s)
+}
+
+// displayWidth returns the number of terminal columns of a string:
+// two for wide and fullwidth East Asian characters, one for all others.
+func displayWidth(s string) int {
+	columns := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			columns += 2
+		default:
+			columns++
+		}
+	}
+	return columns
+}
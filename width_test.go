@@ -0,0 +1,51 @@
+package sprintfjs_test
+
+import (
+	"testing"
+
+	"github.com/crazytyper/go-sprintfjs"
+)
+
+func TestFormatEastAsianWidth(t *testing.T) {
+	formatter := &sprintfjs.Formatter{EastAsianWidth: true}
+	runFormatterTests(t, formatter, []formatterTestcase{
+		ftc(`日本`, `%4s`, "日本"),
+		ftc(`  日本`, `%6s`, "日本"),
+		ftc(`日本  |`, `%-6s|`, "日本"),
+		ftc(` ＡＢ `, `%=6s`, "ＡＢ"),
+		ftc(`  ｱｲ`, `%4s`, "ｱｲ"), // halfwidth katakana
+		ftc(`  abc`, `%5s`, "abc"),
+		ftc(`日本`, `%.2s`, "日本語"), // truncation still counts runes
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`  日本`, `%4s`, "日本"),
+	})
+}
+
+func TestFormatMultiBytePadChar(t *testing.T) {
+	runFormatterTests(t, &sprintfjs.Formatter{}, []formatterTestcase{
+		ftc(`•••••hello`, `%'•10s`, "hello"),
+		ftc(`hello•••••`, `%'•-10s`, "hello"),
+		ftc(`••hello•••`, `%'•=10s`, "hello"),
+		ftc(`••••••-123`, `%+'•10d`, -123),
+		ftc(`中中中中中hello`, `%'中10s`, "hello"),
+		ftc(`中中中日本`, `%'中5s`, "日本"),
+		ftc(`🙂🙂ab`, `%'🙂4s`, "ab"),
+	})
+
+	runFormatterTests(t, &sprintfjs.Formatter{EastAsianWidth: true}, []formatterTestcase{
+		ftc(`中中hello`, `%'中9s`, "hello"),
+		ftc(`中中日本`, `%'中8s`, "日本"),
+		ftc(`中hello`, `%'中8s`, "hello"), // an odd gap cannot be filled by wide characters
+		ftc(`•••hello`, `%'•8s`, "hello"),
+	})
+
+	ast, err := sprintfjs.Parse(`%'中10s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast[0].Pad != `'中` {
+		t.Errorf("expected the pad %q, had %q", `'中`, ast[0].Pad)
+	}
+}